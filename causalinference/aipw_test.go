@@ -0,0 +1,37 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAIPWRecoversEffect(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	res, err := EstimateAIPW(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The outcome model is correctly specified, so the estimate should be close
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.2 {
+		t.Errorf("AIPW estimate %.4f too far from true effect %.1f", res.Estimate, data.TrueEffect)
+	}
+	if res.SE <= 0 || res.CI[0] >= res.CI[1] {
+		t.Errorf("invalid uncertainty: SE=%f CI=%v", res.SE, res.CI)
+	}
+	if res.N != 5000 {
+		t.Errorf("N = %d, want 5000", res.N)
+	}
+}
+
+func TestAIPWEmptyArm(t *testing.T) {
+	data := GenerateCausalData(50, 1)
+	for i := range data.Treatment {
+		data.Treatment[i] = 0
+	}
+
+	if _, err := EstimateAIPW(data); err != ErrEmptyArm {
+		t.Errorf("expected ErrEmptyArm, got %v", err)
+	}
+}
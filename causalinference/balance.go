@@ -0,0 +1,91 @@
+package causalinference
+
+import "math"
+
+// BalanceStats compares one covariate between the arms
+type BalanceStats struct {
+	Means [2]float64 // covariate mean by arm, indexed by treatment
+	// SMD is the treated minus control mean over the pooled unadjusted
+	// standard deviation
+	SMD           float64
+	VarianceRatio float64 // treated variance over control variance
+}
+
+// CovariateBalance describes the balance of one covariate before and after
+// adjustment
+type CovariateBalance struct {
+	Covariate  string       // covariate name
+	Unadjusted BalanceStats // balance in the raw sample
+	Adjusted   BalanceStats // balance under the weights
+}
+
+// BalanceTable computes standardized mean differences and variance ratios
+// of every covariate in the raw sample and under the given unit weights,
+// such as WeightingResult.Weights or matching weights, like
+// cobalt::bal.tab. Both SMDs share the pooled standard deviation
+// sqrt((s1^2 + s0^2)/2) of the unadjusted arms, so they are comparable, and
+// weighted variances carry the usual bias correction. A nil weights leaves
+// the adjusted balance equal to the unadjusted one; otherwise there must
+// be one weight per unit, or ErrLengthMismatch is returned.
+func BalanceTable(data *CausalData, weights []float64) ([]CovariateBalance, error) {
+	if err := requireBothArms(data); err != nil {
+		return nil, err
+	}
+	if weights != nil && len(weights) != len(data.Treatment) {
+		return nil, ErrLengthMismatch
+	}
+
+	rows := covariateRows(data)
+	p := len(rows[0])
+	unit := make([]float64, len(rows))
+	for i := range unit {
+		unit[i] = 1
+	}
+	if weights == nil {
+		weights = unit
+	}
+
+	table := make([]CovariateBalance, p)
+	column := make([]float64, len(rows))
+	for j := range table {
+		for i, row := range rows {
+			column[i] = row[j]
+		}
+		raw, rawVar := armMoments(column, data.Treatment, unit)
+		adj, adjVar := armMoments(column, data.Treatment, weights)
+		sd := math.Sqrt((rawVar[0] + rawVar[1]) / 2)
+
+		table[j] = CovariateBalance{
+			Covariate:  data.CovariateName(j),
+			Unadjusted: BalanceStats{Means: raw, SMD: (raw[1] - raw[0]) / sd, VarianceRatio: rawVar[1] / rawVar[0]},
+			Adjusted:   BalanceStats{Means: adj, SMD: (adj[1] - adj[0]) / sd, VarianceRatio: adjVar[1] / adjVar[0]},
+		}
+	}
+	return table, nil
+}
+
+// armMoments returns the weighted mean and bias-corrected weighted
+// variance sum w / ((sum w)^2 - sum w^2) * sum w (v - m)^2 of v within
+// each arm
+func armMoments(v []float64, treatment []int, weights []float64) ([2]float64, [2]float64) {
+	var sum, total, squares [2]float64
+	for i, x := range v {
+		g := treatment[i]
+		sum[g] += weights[i] * x
+		total[g] += weights[i]
+		squares[g] += weights[i] * weights[i]
+	}
+	var mean, variance [2]float64
+	for g := range mean {
+		mean[g] = sum[g] / total[g]
+	}
+	for i, x := range v {
+		g := treatment[i]
+		d := x - mean[g]
+		variance[g] += weights[i] * d * d
+	}
+	for g := range variance {
+		variance[g] *= total[g] / (total[g]*total[g] - squares[g])
+	}
+	return mean, variance
+}
@@ -0,0 +1,48 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBalanceTable(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	raw, err := BalanceTable(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := raw[0]
+	if b.Covariate != "X" || b.Adjusted != b.Unadjusted {
+		t.Errorf("unweighted table %+v", b)
+	}
+
+	// Compare with the textbook formulas on the raw arms
+	var arms [2][]float64
+	for i, g := range data.Treatment {
+		arms[g] = append(arms[g], data.X[i][0])
+	}
+	m1, _ := meanAndSE(arms[1])
+	m0, _ := meanAndSE(arms[0])
+	s1, s0 := stdDev(arms[1]), stdDev(arms[0])
+	if smd := (m1 - m0) / math.Sqrt((s1*s1+s0*s0)/2); math.Abs(b.Unadjusted.SMD-smd) > 1e-9 {
+		t.Errorf("SMD %.6f, want %.6f", b.Unadjusted.SMD, smd)
+	}
+	if vr := s1 * s1 / (s0 * s0); math.Abs(b.Unadjusted.VarianceRatio-vr) > 1e-9 {
+		t.Errorf("variance ratio %.6f, want %.6f", b.Unadjusted.VarianceRatio, vr)
+	}
+
+	// Entropy balancing equalizes the weighted means exactly
+	weights, err := EntropyBalanceWeights(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adj, _ := BalanceTable(data, weights)
+	if b.Unadjusted.SMD < 0.5 || math.Abs(adj[0].Adjusted.SMD) > 1e-4 {
+		t.Errorf("SMD %.4f before and %.4f after balancing", b.Unadjusted.SMD, adj[0].Adjusted.SMD)
+	}
+
+	if _, err := BalanceTable(data, weights[1:]); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// BayesOptions configures the Bayesian outcome model
+type BayesOptions struct {
+	Draws  int // posterior draws kept; 0 means 2000
+	Burnin int // initial draws discarded; 0 means 500
+	// PriorMean and PriorSD give the normal prior on the treatment effect;
+	// a zero PriorSD means 10, which is close to flat for typical outcomes
+	PriorMean float64
+	PriorSD   float64
+	Seed      int64 // seed for the sampler
+}
+
+// BayesResult holds posterior summaries of the treatment effect. The
+// embedded EffectResult reports the posterior mean, the posterior standard
+// deviation as SE and the 95% equal-tailed credible interval as CI.
+type BayesResult struct {
+	EffectResult
+	Draws []float64 // posterior draws of the treatment effect, in sampling order
+	Sigma []float64 // matching draws of the residual standard deviation
+}
+
+// Vague priors on the nuisance parameters: normal with standard deviation
+// bayesCoefSD on the intercept and covariate slopes, and an inverse gamma
+// with shape and rate bayesVarPrior on the residual variance
+const (
+	bayesCoefSD   = 100
+	bayesVarPrior = 0.01
+)
+
+// EstimateBayesian fits the normal linear model Y = a + tau*T + b'X + e,
+// e ~ N(0, sigma^2), by Gibbs sampling and reports the posterior of tau.
+// Given sigma^2 the coefficients have a multivariate normal full
+// conditional; given the coefficients sigma^2 is inverse gamma. Both are
+// drawn exactly, so no tuning is needed.
+func EstimateBayesian(data *CausalData, opts BayesOptions) (BayesResult, error) {
+	draws := opts.Draws
+	if draws < 1 {
+		draws = 2000
+	}
+	burnin := opts.Burnin
+	if burnin < 1 {
+		burnin = 500
+	}
+	priorSD := opts.PriorSD
+	if priorSD <= 0 {
+		priorSD = 10
+	}
+	if err := requireBothArms(data); err != nil {
+		return BayesResult{}, err
+	}
+
+	rows := covariateRows(data)
+	x := make([][]float64, len(rows))
+	for i, row := range rows {
+		x[i] = append([]float64{1, float64(data.Treatment[i])}, row...)
+	}
+	y := data.Outcome
+	n, p := len(x), len(x[0])
+
+	// Sufficient statistics X'X and X'y
+	xtx := make([][]float64, p)
+	xty := make([]float64, p)
+	for j := range xtx {
+		xtx[j] = make([]float64, p)
+	}
+	for i, row := range x {
+		for j := 0; j < p; j++ {
+			xty[j] += row[j] * y[i]
+			for k := 0; k < p; k++ {
+				xtx[j][k] += row[j] * row[k]
+			}
+		}
+	}
+
+	// Prior precision and precision-weighted prior mean
+	prec := make([]float64, p)
+	shift := make([]float64, p)
+	for j := range prec {
+		prec[j] = 1 / (bayesCoefSD * bayesCoefSD)
+	}
+	prec[1] = 1 / (priorSD * priorSD)
+	shift[1] = opts.PriorMean * prec[1]
+
+	// Start from least squares
+	beta, err := leastSquaresQR(x, y)
+	if err != nil {
+		return BayesResult{}, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	res := BayesResult{Draws: make([]float64, 0, draws), Sigma: make([]float64, 0, draws)}
+	for it := 0; it < burnin+draws; it++ {
+		// sigma^2 | beta ~ InvGamma(a + n/2, b + RSS/2)
+		var rss float64
+		for i, row := range x {
+			e := y[i] - dot(row, beta)
+			rss += e * e
+		}
+		sigma2 := (bayesVarPrior + rss/2) / gammaVariate(rng, bayesVarPrior+float64(n)/2)
+
+		// beta | sigma^2 ~ N(V (X'y/sigma^2 + P m), V), V = (X'X/sigma^2 + P)^-1
+		post := make([][]float64, p)
+		rhs := make([]float64, p)
+		for j := range post {
+			post[j] = make([]float64, p)
+			for k := range post[j] {
+				post[j][k] = xtx[j][k] / sigma2
+			}
+			post[j][j] += prec[j]
+			rhs[j] = xty[j]/sigma2 + shift[j]
+		}
+		cov, err := invertMatrix(post)
+		if err != nil {
+			return BayesResult{}, err
+		}
+		l, err := cholesky(cov)
+		if err != nil {
+			return BayesResult{}, err
+		}
+		mean := make([]float64, p)
+		z := make([]float64, p)
+		for j := range mean {
+			mean[j] = dot(cov[j], rhs)
+			z[j] = rng.NormFloat64()
+		}
+		for j := range beta {
+			beta[j] = mean[j] + dot(l[j][:j+1], z[:j+1])
+		}
+
+		if it >= burnin {
+			res.Draws = append(res.Draws, beta[1])
+			res.Sigma = append(res.Sigma, math.Sqrt(sigma2))
+		}
+	}
+
+	var sum float64
+	for _, d := range res.Draws {
+		sum += d
+	}
+	sorted := append([]float64(nil), res.Draws...)
+	sort.Float64s(sorted)
+	res.EffectResult = EffectResult{
+		Estimate: sum / float64(draws),
+		SE:       stdDev(res.Draws),
+		CI:       [2]float64{sortedQuantile(sorted, 0.025), sortedQuantile(sorted, 0.975)},
+		N:        n,
+		Method:   "BayesianLinear",
+	}.tested()
+	return res, nil
+}
@@ -0,0 +1,33 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateBayesian(t *testing.T) {
+	data := GenerateCausalData(1000, 42)
+
+	res, err := EstimateBayesian(data, BayesOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Draws) != 2000 || len(res.Sigma) != 2000 {
+		t.Fatalf("got %d draws", len(res.Draws))
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("credible interval [%.3f, %.3f] misses %.1f", res.CI[0], res.CI[1], data.TrueEffect)
+	}
+
+	// With a vague prior the posterior matches least squares
+	ols, _ := EstimateRegressionAdjustment(data, RegressionOptions{})
+	if math.Abs(res.Estimate-ols.Estimate) > 0.02 || math.Abs(res.SE/ols.SE-1) > 0.15 {
+		t.Errorf("posterior %.3f (%.3f), OLS %.3f (%.3f)", res.Estimate, res.SE, ols.Estimate, ols.SE)
+	}
+
+	// A tight prior pulls the estimate toward its mean
+	tight, _ := EstimateBayesian(data, BayesOptions{PriorMean: 0, PriorSD: 0.01, Seed: 1})
+	if math.Abs(tight.Estimate) > 0.5 {
+		t.Errorf("tight prior estimate = %.3f", tight.Estimate)
+	}
+}
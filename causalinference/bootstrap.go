@@ -0,0 +1,212 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ErrIgnoresWeights is returned by the Bayesian bootstrap when the
+// estimator gives the same answer under every set of unit weights
+var ErrIgnoresWeights = errors.New("causalinference: estimator ignores CausalData.Weights")
+
+// BootstrapOptions configures Bootstrap
+type BootstrapOptions struct {
+	Level float64 // confidence level of the intervals; 0 means 0.95
+	Seed  int64   // seed for resampling
+	// Bayesian draws Dirichlet unit weights instead of resampling units
+	// (Rubin, 1981). Every unit keeps a positive weight, so no draw loses
+	// an arm. The estimator must honour CausalData.Weights.
+	Bayesian bool
+}
+
+// BootstrapResult holds a bootstrap distribution and intervals built from
+// it. The embedded EffectResult has the original estimate, the bootstrap
+// standard error and the percentile interval as CI; its p-value is the
+// normal approximation with the bootstrap standard error.
+type BootstrapResult struct {
+	EffectResult
+	Draws      []float64  // estimate on each successful resample, in draw order
+	Percentile [2]float64 // percentile interval
+	// BCa is the bias-corrected and accelerated interval of Efron (1987),
+	// with the acceleration estimated by the jackknife; the Bayesian
+	// bootstrap reports its percentile interval here
+	BCa    [2]float64
+	Failed int // resamples on which the estimator returned an error
+}
+
+// Bootstrap resamples units with replacement b times (0 means 1000),
+// re-runs the estimator on each resample and forms percentile and BCa
+// intervals, as boot.ci does. With Bayesian set, each draw reweights the
+// units instead. Resamples on which the estimator fails, for
+// example because one arm is empty, are dropped and counted in Failed.
+// The BCa acceleration needs n further leave-one-out fits. Resamples and
+// jackknife fits run in parallel, so the estimator must be safe for
+// concurrent use; results do not depend on scheduling.
+func Bootstrap(estimate Estimator, data *CausalData, b int, opts BootstrapOptions) (BootstrapResult, error) {
+	if b < 1 {
+		b = 1000
+	}
+	level := opts.Level
+	if level <= 0 || level >= 1 {
+		level = 0.95
+	}
+
+	original, err := estimate(data)
+	if err != nil {
+		return BootstrapResult{}, err
+	}
+
+	n := len(data.Outcome)
+	draws, errs := parallelEstimates(estimate, b, func(r int) *CausalData {
+		// Seed per resample so results don't depend on scheduling
+		rng := rand.New(rand.NewSource(opts.Seed + int64(r)))
+		if opts.Bayesian {
+			return dirichletReweight(data, rng)
+		}
+		units := make([]int, n)
+		for i := range units {
+			units[i] = rng.Intn(n)
+		}
+		return subsetData(data, units)
+	})
+	res := BootstrapResult{}
+	for r, err := range errs {
+		if err != nil {
+			res.Failed++
+			continue
+		}
+		res.Draws = append(res.Draws, draws[r])
+	}
+	if len(res.Draws) < 2 {
+		return BootstrapResult{}, ErrEmptyArm
+	}
+
+	sorted := append([]float64(nil), res.Draws...)
+	sort.Float64s(sorted)
+	tail := (1 - level) / 2
+	res.Percentile = [2]float64{sortedQuantile(sorted, tail), sortedQuantile(sorted, 1-tail)}
+	res.EffectResult = original
+	res.SE = stdDev(res.Draws)
+	res.CI = res.Percentile
+	res.DF = 0
+	res.EffectResult = res.tested()
+
+	if opts.Bayesian {
+		if sorted[0] == sorted[len(sorted)-1] {
+			return BootstrapResult{}, ErrIgnoresWeights
+		}
+		res.BCa = res.Percentile
+		return res, nil
+	}
+
+	// Leave-one-out estimates for the acceleration
+	jack, errs := leaveGroupOut(estimate, data, clusterRows(n, nil))
+	var kept []float64
+	for i, err := range errs {
+		if err == nil {
+			kept = append(kept, jack[i])
+		}
+	}
+	res.BCa = bcaInterval(sorted, original.Estimate, kept, tail)
+	return res, nil
+}
+
+// dirichletReweight returns a copy of data whose unit weights are scaled
+// by a flat Dirichlet draw, normalised to average one
+func dirichletReweight(data *CausalData, rng *rand.Rand) *CausalData {
+	n := len(data.Outcome)
+	out := subsetData(data, allUnits(n))
+	out.Weights = make([]float64, n)
+	var total float64
+	for i := range out.Weights {
+		out.Weights[i] = rng.ExpFloat64()
+		if data.Weights != nil {
+			out.Weights[i] *= data.Weights[i]
+		}
+		total += out.Weights[i]
+	}
+	for i := range out.Weights {
+		out.Weights[i] *= float64(n) / total
+	}
+	return out
+}
+
+// parallelEstimates runs the estimator on count datasets built by
+// dataset, spread over runtime.NumCPU() workers
+func parallelEstimates(estimate Estimator, count int, dataset func(int) *CausalData) ([]float64, []error) {
+	results, errs := parallelResults(estimate, count, dataset)
+	out := make([]float64, count)
+	for k, res := range results {
+		out[k] = res.Estimate
+	}
+	return out, errs
+}
+
+// parallelResults is parallelEstimates keeping the full results
+func parallelResults(estimate Estimator, count int, dataset func(int) *CausalData) ([]EffectResult, []error) {
+	out := make([]EffectResult, count)
+	errs := make([]error, count)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				out[k], errs[k] = estimate(dataset(k))
+			}
+		}()
+	}
+	for k := 0; k < count; k++ {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+	return out, errs
+}
+
+// bcaInterval adjusts the percentile levels for the median bias of the
+// sorted draws around the original estimate and for the skewness of the
+// jackknife estimates. It falls back to the percentile interval when the
+// corrections are undefined.
+func bcaInterval(sorted []float64, original float64, jack []float64, tail float64) [2]float64 {
+	var below float64
+	for _, d := range sorted {
+		if d < original {
+			below++
+		} else if d == original {
+			below += 0.5
+		}
+	}
+	z0 := normalQuantile(below / float64(len(sorted)))
+
+	var mean float64
+	for _, j := range jack {
+		mean += j / float64(len(jack))
+	}
+	var num, den float64
+	for _, j := range jack {
+		d := mean - j
+		num += d * d * d
+		den += d * d
+	}
+	var a float64
+	if den > 0 {
+		a = num / (6 * math.Pow(den, 1.5))
+	}
+
+	var ci [2]float64
+	for k, q := range [2]float64{tail, 1 - tail} {
+		z := z0 + normalQuantile(q)
+		p := normalCDF(z0 + z/(1-a*z))
+		if math.IsNaN(p) || math.IsInf(z0, 0) {
+			p = q
+		}
+		ci[k] = sortedQuantile(sorted, p)
+	}
+	return ci
+}
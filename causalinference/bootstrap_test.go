@@ -0,0 +1,94 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBootstrap(t *testing.T) {
+	data := GenerateCausalData(500, 42)
+
+	res, err := Bootstrap(EstimateCausalEffect, data, 500, BootstrapOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analytic, _ := EstimateCausalEffect(data)
+	if res.Estimate != analytic.Estimate {
+		t.Error("bootstrap should report the original estimate")
+	}
+	if math.Abs(res.SE/analytic.SE-1) > 0.15 {
+		t.Errorf("bootstrap SE %.4f, analytic %.4f", res.SE, analytic.SE)
+	}
+	if res.CI != res.Percentile || len(res.Draws)+res.Failed != 500 {
+		t.Errorf("CI %v, percentile %v, %d draws", res.CI, res.Percentile, len(res.Draws))
+	}
+	// Nearly symmetric statistic: BCa stays close to the percentile interval
+	for k := range res.BCa {
+		if math.Abs(res.BCa[k]-res.Percentile[k]) > res.SE/2 {
+			t.Errorf("BCa %v far from percentile %v", res.BCa, res.Percentile)
+		}
+	}
+
+	again, _ := Bootstrap(EstimateCausalEffect, data, 500, BootstrapOptions{Seed: 1})
+	if again.BCa != res.BCa {
+		t.Error("results depend on scheduling")
+	}
+}
+
+func TestBootstrapBCaSkew(t *testing.T) {
+	// The mean of exponential draws is right-skewed; BCa shifts the
+	// interval right of the percentile interval
+	rng := rand.New(rand.NewSource(7))
+	data := &CausalData{X: make([][]float64, 60), Treatment: make([]int, 60), Outcome: make([]float64, 60)}
+	for i := range data.Outcome {
+		data.Outcome[i] = rng.ExpFloat64()
+	}
+	mean := func(d *CausalData) (EffectResult, error) {
+		m, se := meanAndSE(d.Outcome)
+		return newEffectResult("Mean", m, se, len(d.Outcome)), nil
+	}
+
+	res, err := Bootstrap(mean, data, 2000, BootstrapOptions{Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BCa[0] <= res.Percentile[0] || res.BCa[1] <= res.Percentile[1] {
+		t.Errorf("BCa %v not shifted right of percentile %v", res.BCa, res.Percentile)
+	}
+}
+
+func TestBayesianBootstrap(t *testing.T) {
+	data := GenerateCausalData(500, 42)
+
+	classic, _ := Bootstrap(EstimateCausalEffect, data, 500, BootstrapOptions{Seed: 1})
+	bayes, err := Bootstrap(EstimateCausalEffect, data, 500, BootstrapOptions{Seed: 1, Bayesian: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(bayes.SE/classic.SE-1) > 0.15 || bayes.BCa != bayes.Percentile {
+		t.Errorf("Bayesian SE %.4f, classical %.4f", bayes.SE, classic.SE)
+	}
+	if reg, err := Bootstrap(regressionEstimator, data, 100, BootstrapOptions{Bayesian: true}); err != nil || reg.SE == 0 {
+		t.Errorf("regression adjustment should honour weights: %v", err)
+	}
+
+	// With two treated units many resamples lose the arm; reweighting never does
+	small := subsetData(data, allUnits(40))
+	for i := range small.Treatment {
+		small.Treatment[i] = 0
+	}
+	small.Treatment[3], small.Treatment[17] = 1, 1
+	classic, _ = Bootstrap(EstimateCausalEffect, small, 200, BootstrapOptions{Seed: 2})
+	bayes, _ = Bootstrap(EstimateCausalEffect, small, 200, BootstrapOptions{Seed: 2, Bayesian: true})
+	if classic.Failed == 0 || bayes.Failed != 0 {
+		t.Errorf("failed draws: classical %d, Bayesian %d", classic.Failed, bayes.Failed)
+	}
+
+	ignores := func(d *CausalData) (EffectResult, error) {
+		return newEffectResult("Constant", 1, 0, len(d.Outcome)), nil
+	}
+	if _, err := Bootstrap(ignores, data, 50, BootstrapOptions{Bayesian: true}); err != ErrIgnoresWeights {
+		t.Errorf("expected ErrIgnoresWeights, got %v", err)
+	}
+}
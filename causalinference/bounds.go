@@ -0,0 +1,59 @@
+package causalinference
+
+// BoundsResult holds partial identification bounds on the ATE
+type BoundsResult struct {
+	// WorstCase assumes nothing about the unobserved potential outcomes
+	// beyond the outcome support
+	WorstCase [2]float64
+	// MTR adds monotone treatment response, Y(1) >= Y(0) for every unit,
+	// which raises the lower bound to zero
+	MTR     [2]float64
+	Support [2]float64 // outcome range assumed for the unobserved potential outcomes
+	N       int        // number of units
+}
+
+// EstimateBounds returns Manski's no-assumption bounds on the ATE and the
+// tighter bounds under monotone treatment response. Each unobserved
+// potential outcome is replaced by the lowest or highest value the outcome
+// can take, here the observed minimum and maximum, so the worst-case
+// interval always has width max - min and always contains zero. The
+// bounds are sample analogues without sampling uncertainty.
+func EstimateBounds(data *CausalData) (BoundsResult, error) {
+	if err := requireBothArms(data); err != nil {
+		return BoundsResult{}, err
+	}
+
+	lo, hi := data.Outcome[0], data.Outcome[0]
+	var sum [2]float64
+	var count [2]float64
+	for i, y := range data.Outcome {
+		if y < lo {
+			lo = y
+		}
+		if y > hi {
+			hi = y
+		}
+		sum[data.Treatment[i]] += y
+		count[data.Treatment[i]]++
+	}
+	n := count[0] + count[1]
+	p := count[1] / n
+	m1, m0 := sum[1]/count[1], sum[0]/count[0]
+
+	// E[Y(1)] is known for the treated and bounded by the support for controls
+	y1 := [2]float64{p*m1 + (1-p)*lo, p*m1 + (1-p)*hi}
+	y0 := [2]float64{(1-p)*m0 + p*lo, (1-p)*m0 + p*hi}
+	worst := [2]float64{y1[0] - y0[1], y1[1] - y0[0]}
+
+	mtr := worst
+	if mtr[0] < 0 {
+		mtr[0] = 0
+	}
+
+	return BoundsResult{
+		WorstCase: worst,
+		MTR:       mtr,
+		Support:   [2]float64{lo, hi},
+		N:         int(n),
+	}, nil
+}
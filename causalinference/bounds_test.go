@@ -0,0 +1,31 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateBounds(t *testing.T) {
+	data := GenerateCausalData(2000, 42)
+
+	res, err := EstimateBounds(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := res.WorstCase
+	if w[0] > data.TrueEffect || w[1] < data.TrueEffect {
+		t.Errorf("worst-case bounds [%.3f, %.3f] exclude the true effect", w[0], w[1])
+	}
+	// No-assumption bounds always have width equal to the outcome range
+	if width := w[1] - w[0]; math.Abs(width-(res.Support[1]-res.Support[0])) > 1e-9 {
+		t.Errorf("width %.3f, support %v", width, res.Support)
+	}
+	if res.MTR[0] != 0 || res.MTR[1] != w[1] {
+		t.Errorf("MTR bounds = %v, worst case = %v", res.MTR, w)
+	}
+
+	data.Treatment = make([]int, len(data.Treatment))
+	if _, err := EstimateBounds(data); err != ErrEmptyArm {
+		t.Errorf("expected ErrEmptyArm, got %v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// CausalForestOptions configures an honest causal forest
+type CausalForestOptions struct {
+	Trees       int   // number of trees, rounded up to an even number; 0 means 200
+	MinLeafSize int   // minimum treated and control units per leaf; 0 means 5
+	MaxDepth    int   // maximum tree depth; 0 means 10
+	Seed        int64 // seed for subsampling
+}
+
+// minForestUnits is the smallest sample whose grow subsets are nonempty
+const minForestUnits = 8
+
+// CATEResult holds per-unit conditional average treatment effect estimates
+type CATEResult struct {
+	CATE          []float64         // estimated effect for each unit
+	Variance      []float64         // variance of each CATE estimate, when available
+	ATE           float64           // average of the CATE estimates
+	Heterogeneity HeterogeneityTest // calibration test of the estimates, see CalibrationTest
+}
+
+// EstimateCausalForest fits an honest causal forest in the style of grf.
+// Trees are grown in pairs ("little bags") that share a half-sample; each
+// tree subsamples half of its bag, grows its splits on one half of that
+// subsample by maximising the difference in treatment effects between
+// children, and estimates leaf effects as differences in means on the
+// other half. Per-unit variances come from the between-bag spread of
+// predictions, corrected for within-bag noise. Trees are built in parallel.
+// Each tree grows on an eighth of the units, so fewer than
+// minForestUnits units return ErrNoObservations, as do samples so small
+// or unbalanced that too few units receive a finite prediction for the
+// calibration test.
+func EstimateCausalForest(data *CausalData, opts CausalForestOptions) (CATEResult, error) {
+	trees := opts.Trees
+	if trees < 1 {
+		trees = 200
+	}
+	bags := (trees + 1) / 2
+	minLeaf := opts.MinLeafSize
+	if minLeaf < 1 {
+		minLeaf = 5
+	}
+	maxDepth := opts.MaxDepth
+	if maxDepth < 1 {
+		maxDepth = 10
+	}
+
+	rows := covariateRows(data)
+	n := len(rows)
+	var treated int
+	for _, t := range data.Treatment {
+		treated += t
+	}
+	if treated == 0 || treated == n {
+		return CATEResult{}, ErrEmptyArm
+	}
+	if n < minForestUnits {
+		return CATEResult{}, ErrNoObservations
+	}
+
+	// preds[b][k][i] is tree k of bag b's prediction for unit i
+	preds := make([][2][]float64, bags)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				// Seed per bag so results don't depend on scheduling
+				rng := rand.New(rand.NewSource(opts.Seed + int64(b)))
+				bag := rng.Perm(n)[:n/2]
+				for k := 0; k < 2; k++ {
+					sub := append([]int(nil), bag...)
+					rng.Shuffle(len(sub), func(a, c int) { sub[a], sub[c] = sub[c], sub[a] })
+					sub = sub[:len(sub)/2]
+					grow, estimate := sub[:len(sub)/2], sub[len(sub)/2:]
+
+					tree := &causalTree{rows: rows, data: data, minLeaf: minLeaf, maxDepth: maxDepth}
+					root := tree.grow(grow, 0)
+					tree.estimate(root, estimate)
+
+					p := make([]float64, n)
+					for i, row := range rows {
+						p[i] = root.predict(row)
+					}
+					preds[b][k] = p
+				}
+			}
+		}()
+	}
+	for b := 0; b < bags; b++ {
+		jobs <- b
+	}
+	close(jobs)
+	wg.Wait()
+
+	res := CATEResult{CATE: make([]float64, n), Variance: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		var total, between, within float64
+		var count int
+		bagMeans := make([]float64, 0, bags)
+		for b := range preds {
+			p0, p1 := preds[b][0][i], preds[b][1][i]
+			if math.IsNaN(p0) || math.IsNaN(p1) {
+				continue
+			}
+			m := (p0 + p1) / 2
+			bagMeans = append(bagMeans, m)
+			total += m
+			within += (p0-m)*(p0-m) + (p1-m)*(p1-m)
+			count++
+		}
+		if count == 0 {
+			res.CATE[i] = math.NaN()
+			res.Variance[i] = math.NaN()
+			continue
+		}
+		mean := total / float64(count)
+		for _, m := range bagMeans {
+			between += (m - mean) * (m - mean)
+		}
+		res.CATE[i] = mean
+
+		// Between-bag variance minus the part explained by within-bag noise
+		v := between/float64(count) - within/float64(count)/2
+		res.Variance[i] = math.Max(v, 0)
+	}
+
+	var sum float64
+	var used int
+	for _, c := range res.CATE {
+		if !math.IsNaN(c) {
+			sum += c
+			used++
+		}
+	}
+	res.ATE = sum / float64(used)
+
+	var err error
+	res.Heterogeneity, err = CalibrationTest(data, res.CATE)
+	if err != nil {
+		return CATEResult{}, err
+	}
+	return res, nil
+}
+
+// causalTree grows one honest tree over the given dataset
+type causalTree struct {
+	rows     [][]float64
+	data     *CausalData
+	minLeaf  int
+	maxDepth int
+}
+
+type causalNode struct {
+	feature     int
+	threshold   float64
+	left, right *causalNode
+	effect      float64 // honest leaf estimate, NaN if an arm is missing
+}
+
+// grow recursively splits units to maximise effect heterogeneity
+func (t *causalTree) grow(units []int, depth int) *causalNode {
+	node := &causalNode{effect: math.NaN()}
+	if depth >= t.maxDepth || len(units) == 0 {
+		return node
+	}
+
+	bestGain := 0.0
+	bestFeature, bestThreshold := -1, 0.0
+	for f := range t.rows[0] {
+		gain, threshold, ok := t.bestSplit(units, f)
+		if ok && gain > bestGain {
+			bestGain, bestFeature, bestThreshold = gain, f, threshold
+		}
+	}
+	if bestFeature < 0 {
+		return node
+	}
+
+	var left, right []int
+	for _, i := range units {
+		if t.rows[i][bestFeature] <= bestThreshold {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+	node.feature, node.threshold = bestFeature, bestThreshold
+	node.left = t.grow(left, depth+1)
+	node.right = t.grow(right, depth+1)
+	return node
+}
+
+// bestSplit scans sorted values of feature f and returns the split that
+// maximises nL*nR*(tauL - tauR)^2, keeping minLeaf units of each arm per side
+func (t *causalTree) bestSplit(units []int, f int) (float64, float64, bool) {
+	sorted := append([]int(nil), units...)
+	sort.Slice(sorted, func(a, b int) bool { return t.rows[sorted[a]][f] < t.rows[sorted[b]][f] })
+
+	var totSum, totCount [2]float64
+	for _, i := range sorted {
+		g := t.data.Treatment[i]
+		totSum[g] += t.data.Outcome[i]
+		totCount[g]++
+	}
+
+	var lSum, lCount [2]float64
+	best, threshold, found := 0.0, 0.0, false
+	for k := 0; k < len(sorted)-1; k++ {
+		i := sorted[k]
+		g := t.data.Treatment[i]
+		lSum[g] += t.data.Outcome[i]
+		lCount[g]++
+
+		// Only split between distinct values
+		if t.rows[sorted[k+1]][f] == t.rows[i][f] {
+			continue
+		}
+		m := float64(t.minLeaf)
+		if lCount[0] < m || lCount[1] < m || totCount[0]-lCount[0] < m || totCount[1]-lCount[1] < m {
+			continue
+		}
+
+		tauL := lSum[1]/lCount[1] - lSum[0]/lCount[0]
+		tauR := (totSum[1]-lSum[1])/(totCount[1]-lCount[1]) - (totSum[0]-lSum[0])/(totCount[0]-lCount[0])
+		nL := lCount[0] + lCount[1]
+		nR := float64(len(sorted)) - nL
+		if gain := nL * nR * (tauL - tauR) * (tauL - tauR); gain > best {
+			best, found = gain, true
+			threshold = (t.rows[i][f] + t.rows[sorted[k+1]][f]) / 2
+		}
+	}
+	return best, threshold, found
+}
+
+// estimate fills leaf effects using only the held-out estimation units
+func (t *causalTree) estimate(node *causalNode, units []int) {
+	if node.left == nil {
+		var sum, count [2]float64
+		for _, i := range units {
+			g := t.data.Treatment[i]
+			sum[g] += t.data.Outcome[i]
+			count[g]++
+		}
+		if count[0] > 0 && count[1] > 0 {
+			node.effect = sum[1]/count[1] - sum[0]/count[0]
+		}
+		return
+	}
+
+	var left, right []int
+	for _, i := range units {
+		if t.rows[i][node.feature] <= node.threshold {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+	t.estimate(node.left, left)
+	t.estimate(node.right, right)
+}
+
+// predict returns the leaf effect for a covariate row
+func (n *causalNode) predict(row []float64) float64 {
+	for n.left != nil {
+		if row[n.feature] <= n.threshold {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return n.effect
+}
@@ -0,0 +1,77 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCausalForest(t *testing.T) {
+	data := GenerateCausalData(4000, 123)
+
+	// Make the effect vary with X: 5 for X < 0 and 8 for X >= 0
+	for i, x := range data.Column(0) {
+		if x >= 0 && data.Treatment[i] == 1 {
+			data.Outcome[i] += 3
+		}
+	}
+
+	res, err := EstimateCausalForest(data, CausalForestOptions{Trees: 50, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare average CATEs on each side of the split in the effect
+	var lo, hi, nLo, nHi float64
+	for i, x := range data.Column(0) {
+		if math.IsNaN(res.CATE[i]) || math.Abs(x) > 0.8 || math.Abs(x) < 0.3 {
+			continue
+		}
+		if x < 0 {
+			lo += res.CATE[i]
+			nLo++
+		} else {
+			hi += res.CATE[i]
+			nHi++
+		}
+		if res.Variance[i] < 0 {
+			t.Fatalf("negative variance at unit %d", i)
+		}
+	}
+	if diff := hi/nHi - lo/nLo; diff < 1.5 {
+		t.Errorf("forest found effect difference %.3f across X = 0, want about 3", diff)
+	}
+}
+
+func TestCausalForestSmallSample(t *testing.T) {
+	// Six units leave the grow subsets empty, which is an error, not a panic
+	data := GenerateCausalData(6, 1)
+	data.Treatment = []int{0, 1, 0, 1, 0, 1}
+	if _, err := EstimateCausalForest(data, CausalForestOptions{Trees: 4, Seed: 1}); err != ErrNoObservations {
+		t.Errorf("error %v, want ErrNoObservations", err)
+	}
+
+	// Just above the guard, and with a single treated unit, leaves rarely
+	// hold both arms; too few predictions to calibrate is an error, not a
+	// panic
+	for seed := int64(0); seed < 30; seed++ {
+		if _, err := EstimateCausalForest(GenerateCausalData(minForestUnits, seed), CausalForestOptions{Trees: 4, Seed: seed}); err != nil && err != ErrNoObservations && err != ErrEmptyArm {
+			t.Fatalf("n = %d, seed %d: %v", minForestUnits, seed, err)
+		}
+	}
+	single := GenerateCausalData(50, 2)
+	for i := range single.Treatment {
+		single.Treatment[i] = 0
+	}
+	single.Treatment[7] = 1
+	for seed := int64(0); seed < 30; seed++ {
+		if _, err := EstimateCausalForest(single, CausalForestOptions{Trees: 4, Seed: seed}); err != nil && err != ErrNoObservations {
+			t.Fatalf("one treated unit, seed %d: %v", seed, err)
+		}
+	}
+
+	// An empty grow subset becomes a leaf
+	tree := &causalTree{rows: covariateRows(data), data: data, minLeaf: 1, maxDepth: 3}
+	if node := tree.grow(nil, 0); node.left != nil || !math.IsNaN(node.effect) {
+		t.Errorf("empty units grew %+v, want an empty leaf", node)
+	}
+}
@@ -1,62 +1,188 @@
 package causalinference
 
-import "math/rand"
+import (
+	"fmt"
+	"math"
+)
 
 // CausalData struct for building synthetic data objects
 type CausalData struct {
-	X          []float64 // single covariate
-	Treatment  []int     // 0 or 1
-	Outcome    []float64 // observed outcome
-	TrueEffect float64   // for testing
+	X          [][]float64 // covariates of each unit, one row per unit
+	Names      []string    // name of each covariate column; nil means X, or X1, X2, ... for several
+	Treatment  []int       // 0 or 1; arms 0..K-1 for multi-arm data
+	Outcome    []float64   // observed outcome
+	TrueEffect float64     // for testing
+	// UnitEffects is the true effect of each unit, when the generator
+	// knows it; TrueEffect is then their average
+	UnitEffects []float64
+	ArmEffects  []float64 // true effect of each arm versus arm 0, for testing
+	Cluster     []int     // cluster identifier of each unit; nil means units are independent
+	Instrument  []float64 // instrument Z of each unit, such as a randomized encouragement; nil means none
+	// Weights are unit sampling weights; nil means equal weights. They are
+	// honoured by EstimateCausalEffect and EstimateRegressionAdjustment.
+	Weights []float64
+	// Mask marks the values InjectMissing replaced by NaN; nil means
+	// nothing is missing
+	Mask *MissingMask
+	// ErrorFree holds the values before AddMeasurementError; nil means the
+	// data are measured without error
+	ErrorFree *ErrorFreeValues
 }
 
-// GenerateCausalData creates synthetic data
-func GenerateCausalData(n int, seed int64) *CausalData {
-	rand.Seed(seed)
-
-	data := &CausalData{
-		X:          make([]float64, n),
-		Treatment:  make([]int, n),
-		Outcome:    make([]float64, n),
-		TrueEffect: 5.0,
+// EstimateCausalEffect checks difference in means between treatment and
+// control groups. The standard error is Welch's, sqrt(s1^2/n1 + s0^2/n0),
+// which allows the arms to have different variances, and the interval and
+// p-value come from Welch's t-test with Welch-Satterthwaite degrees of
+// freedom. With unit Weights the means are weighted, each arm's variance
+// is the sandwich sum w^2 (y - m)^2 / (sum w)^2, scaled by n/(n-1) so that
+// equal weights give the Welch value, and the normal reference is used.
+func EstimateCausalEffect(data *CausalData) (EffectResult, error) {
+	if err := requireBothArms(data); err != nil {
+		return EffectResult{}, err
 	}
 
-	for i := 0; i < n; i++ {
-		// Generate basic data
-		data.X[i] = rand.NormFloat64()
+	// Simple means by treatment group
+	var sum, total [2]float64
+	var count [2]int
+	for i, t := range data.Treatment {
+		w := unitWeight(data, i)
+		sum[t] += w * data.Outcome[i]
+		total[t] += w
+		count[t]++
+	}
 
-		// Treatment is more likely for higher X values
-		if rand.Float64() < 0.5*(data.X[i]+1) {
-			data.Treatment[i] = 1
-		}
+	var mean, variance [2]float64
+	for t := range mean {
+		mean[t] = sum[t] / total[t]
+	}
+	for i, t := range data.Treatment {
+		w := unitWeight(data, i)
+		d := data.Outcome[i] - mean[t]
+		variance[t] += w * w * d * d
+	}
+	for t := range variance {
+		n := float64(count[t])
+		variance[t] *= n / (n - 1) / (total[t] * total[t])
+	}
 
-		// Outcome depends on X and treatment
-		data.Outcome[i] = data.X[i] + float64(data.Treatment[i])*data.TrueEffect + rand.NormFloat64()
+	res := newEffectResult("DifferenceInMeans", mean[1]-mean[0], math.Sqrt(variance[0]+variance[1]), len(data.Outcome))
+	if data.Weights != nil {
+		return res, nil
 	}
 
-	return data
+	// Welch t-test with Welch-Satterthwaite degrees of freedom, as in
+	// R's t.test
+	n1, n0 := float64(count[1]), float64(count[0])
+	s := variance[1] + variance[0]
+	res.DF = s * s / (variance[1]*variance[1]/(n1-1) + variance[0]*variance[0]/(n0-1))
+	crit := studentTQuantile(0.975, res.DF)
+	res.CI = [2]float64{res.Estimate - crit*res.SE, res.Estimate + crit*res.SE}
+	return res.tested(), nil
 }
 
-// EstimateCausalEffect checks difference in means between treatment and control groups
-func EstimateCausalEffect(data *CausalData) float64 {
-	var treatSum, controlSum float64
-	var treatCount, controlCount int
+// unitWeight returns unit i's sampling weight, 1 when the data are unweighted
+func unitWeight(data *CausalData, i int) float64 {
+	if data.Weights == nil {
+		return 1
+	}
+	return data.Weights[i]
+}
 
-	// Simple means by treatment group
-	for i := range data.X {
-		if data.Treatment[i] == 1 {
-			treatSum += data.Outcome[i]
-			treatCount++
-		} else {
-			controlSum += data.Outcome[i]
-			controlCount++
+// subsetData returns a new dataset made of the given units, in order.
+// Indices may repeat, which is how bootstrap resamples are built.
+func subsetData(data *CausalData, units []int) *CausalData {
+	out := &CausalData{
+		X:          make([][]float64, len(units)),
+		Names:      data.Names,
+		Treatment:  make([]int, len(units)),
+		Outcome:    make([]float64, len(units)),
+		TrueEffect: data.TrueEffect,
+		ArmEffects: data.ArmEffects,
+	}
+	if data.Cluster != nil {
+		out.Cluster = make([]int, len(units))
+	}
+	if data.Weights != nil {
+		out.Weights = make([]float64, len(units))
+	}
+	if data.UnitEffects != nil {
+		out.UnitEffects = make([]float64, len(units))
+	}
+	if data.Instrument != nil {
+		out.Instrument = make([]float64, len(units))
+	}
+	if data.Mask != nil {
+		out.Mask = &MissingMask{X: make([][]bool, len(units)), Outcome: make([]bool, len(units))}
+	}
+	if data.ErrorFree != nil {
+		out.ErrorFree = &ErrorFreeValues{X: make([][]float64, len(units)), Outcome: make([]float64, len(units))}
+	}
+	for k, i := range units {
+		if out.Cluster != nil {
+			out.Cluster[k] = data.Cluster[i]
+		}
+		if out.Weights != nil {
+			out.Weights[k] = data.Weights[i]
+		}
+		if out.UnitEffects != nil {
+			out.UnitEffects[k] = data.UnitEffects[i]
+		}
+		if out.Instrument != nil {
+			out.Instrument[k] = data.Instrument[i]
 		}
+		if out.Mask != nil {
+			out.Mask.X[k] = data.Mask.X[i]
+			out.Mask.Outcome[k] = data.Mask.Outcome[i]
+		}
+		if out.ErrorFree != nil {
+			out.ErrorFree.X[k] = data.ErrorFree.X[i]
+			out.ErrorFree.Outcome[k] = data.ErrorFree.Outcome[i]
+		}
+		out.X[k] = data.X[i]
+		out.Treatment[k] = data.Treatment[i]
+		out.Outcome[k] = data.Outcome[i]
+	}
+	return out
+}
+
+// Column returns a copy of covariate column j, such as the single
+// covariate of the built-in data generators as Column(0)
+func (d *CausalData) Column(j int) []float64 {
+	col := make([]float64, len(d.X))
+	for i, row := range d.X {
+		col[i] = row[j]
 	}
+	return col
+}
 
-	// Edge case where no treatment or control observations
-	if treatCount == 0 || controlCount == 0 {
-		return 0
+// CovariateName returns the name of covariate column j, falling back to X
+// for a single covariate and X1, X2, ... otherwise when Names is nil
+func (d *CausalData) CovariateName(j int) string {
+	if j < len(d.Names) {
+		return d.Names[j]
+	}
+	p := 0
+	if len(d.X) > 0 {
+		p = len(d.X[0])
+	}
+	if p == 1 {
+		return "X"
 	}
+	return fmt.Sprintf("X%d", j+1)
+}
 
-	return (treatSum / float64(treatCount)) - (controlSum / float64(controlCount))
+// CovariateMatrix builds the rows of X from covariate columns of equal
+// length, so that CovariateMatrix(x) wraps a single covariate
+func CovariateMatrix(columns ...[]float64) [][]float64 {
+	if len(columns) == 0 {
+		return nil
+	}
+	rows := make([][]float64, len(columns[0]))
+	for i := range rows {
+		rows[i] = make([]float64, len(columns))
+		for j, col := range columns {
+			rows[i][j] = col[i]
+		}
+	}
+	return rows
 }
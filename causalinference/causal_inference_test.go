@@ -1,6 +1,10 @@
 package causalinference
 
-import "testing"
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
 
 func TestBasicFunctionality(t *testing.T) {
 	// Generate a tiny dataset and verify basic properties
@@ -12,13 +16,62 @@ func TestBasicFunctionality(t *testing.T) {
 	}
 
 	// Make sure effect is not 0
-	effect := EstimateCausalEffect(data)
+	effect, err := EstimateCausalEffect(data)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	if effect == 0 {
+	if effect.Estimate == 0 {
 		t.Error("Estimated effect should not be zero")
 	}
 }
 
+func TestWelchStandardError(t *testing.T) {
+	data := GenerateCausalData(2000, 7)
+	res, err := EstimateCausalEffect(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare with the unpooled formula sqrt(s1^2/n1 + s0^2/n0)
+	var arms [2][]float64
+	for i, g := range data.Treatment {
+		arms[g] = append(arms[g], data.Outcome[i])
+	}
+	s1, s0 := stdDev(arms[1]), stdDev(arms[0])
+	want := math.Sqrt(s1*s1/float64(len(arms[1])) + s0*s0/float64(len(arms[0])))
+	if math.Abs(res.SE-want) > 1e-12 {
+		t.Errorf("SE = %.6f, want %.6f", res.SE, want)
+	}
+	if res.CI[0] >= res.Estimate || res.CI[1] <= res.Estimate || res.N != 2000 {
+		t.Errorf("bad interval or count: %+v", res)
+	}
+
+	// Welch-Satterthwaite degrees of freedom, and a p-value matching the CI
+	v1, v0 := s1*s1/float64(len(arms[1])), s0*s0/float64(len(arms[0]))
+	df := (v1 + v0) * (v1 + v0) / (v1*v1/float64(len(arms[1])-1) + v0*v0/float64(len(arms[0])-1))
+	if math.Abs(res.DF-df) > 1e-9 {
+		t.Errorf("DF = %.4f, want %.4f", res.DF, df)
+	}
+	if shifted := res.Test(res.CI[0]); math.Abs(shifted.PValue-0.05) > 1e-6 {
+		t.Errorf("p-value at the CI bound = %.6f, want 0.05", shifted.PValue)
+	}
+	if res.PValue > 1e-6 || res.Significance() != "***" {
+		t.Errorf("true effect of %.1f not significant: p = %g", data.TrueEffect, res.PValue)
+	}
+
+	data.Treatment = make([]int, len(data.Treatment))
+	if _, err := EstimateCausalEffect(data); err != ErrEmptyArm {
+		t.Errorf("expected ErrEmptyArm, got %v", err)
+	}
+}
+
+// naiveBias is the absolute error of the unadjusted difference in means
+func naiveBias(data *CausalData) float64 {
+	res, _ := EstimateCausalEffect(data)
+	return math.Abs(res.Estimate - data.TrueEffect)
+}
+
 func BenchmarkAll(b *testing.B) {
 	// Combined benchmark for the entire workflow
 	for i := 0; i < b.N; i++ {
@@ -26,3 +79,55 @@ func BenchmarkAll(b *testing.B) {
 		EstimateCausalEffect(data)
 	}
 }
+
+func TestWeightedDifferenceInMeans(t *testing.T) {
+	// Integer weights give the same estimate as duplicating units
+	data := GenerateCausalData(200, 3)
+	data.Weights = make([]float64, len(data.Outcome))
+	var units []int
+	for i := range data.Weights {
+		data.Weights[i] = float64(1 + i%3)
+		for k := 0; k < 1+i%3; k++ {
+			units = append(units, i)
+		}
+	}
+	weighted, _ := EstimateCausalEffect(data)
+	dup := subsetData(data, units)
+	dup.Weights = nil
+	expanded, _ := EstimateCausalEffect(dup)
+	if math.Abs(weighted.Estimate-expanded.Estimate) > 1e-12 {
+		t.Errorf("weighted %.6f, expanded %.6f", weighted.Estimate, expanded.Estimate)
+	}
+}
+
+func TestMultipleCovariates(t *testing.T) {
+	// Two confounders; adjusting for only the first leaves bias from the second
+	rng := rand.New(rand.NewSource(6))
+	n := 4000
+	x1, x2 := make([]float64, n), make([]float64, n)
+	data := &CausalData{Treatment: make([]int, n), Outcome: make([]float64, n), TrueEffect: 1, Names: []string{"age", "income"}}
+	for i := 0; i < n; i++ {
+		x1[i], x2[i] = rng.NormFloat64(), rng.NormFloat64()
+		if rng.Float64() < sigmoid(x1[i]+x2[i]) {
+			data.Treatment[i] = 1
+		}
+		data.Outcome[i] = x1[i] + 2*x2[i] + float64(data.Treatment[i]) + rng.NormFloat64()
+	}
+	data.X = CovariateMatrix(x1, x2)
+	if data.Column(1)[7] != x2[7] || data.CovariateName(1) != "income" {
+		t.Fatalf("columns or names not kept")
+	}
+
+	both, err := EstimateRegressionAdjustment(data, RegressionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, _ := EstimateRegressionAdjustment(&CausalData{X: CovariateMatrix(x1), Treatment: data.Treatment, Outcome: data.Outcome}, RegressionOptions{})
+	if math.Abs(both.Estimate-1) > 4*both.SE || math.Abs(partial.Estimate-1) < 0.5 {
+		t.Errorf("adjusting for both %.3f, for one %.3f, want 1", both.Estimate, partial.Estimate)
+	}
+
+	if name := (&CausalData{X: CovariateMatrix(x1, x2)}).CovariateName(0); name != "X1" {
+		t.Errorf("default name %q, want X1", name)
+	}
+}
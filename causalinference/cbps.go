@@ -0,0 +1,114 @@
+package causalinference
+
+// CBPSMethod selects which moment conditions CBPS solves
+type CBPSMethod int
+
+const (
+	// CBPSExact solves the balance conditions alone, so the ATE weights
+	// balance the covariate means exactly
+	CBPSExact CBPSMethod = iota
+	// CBPSOver adds the logistic score equations and combines both sets of
+	// moments by two-step GMM
+	CBPSOver
+)
+
+// CBPSOptions configures the covariate balancing propensity score
+type CBPSOptions struct {
+	Method CBPSMethod // moment conditions; defaults to exact balance
+}
+
+// CBPSScores estimates propensity scores with the covariate balancing
+// propensity score of Imai and Ratkovic (2014). The logistic model is fit
+// to satisfy the ATE balance conditions sum[(T/e - (1-T)/(1-e)) x] = 0,
+// starting from the maximum likelihood fit. The over-identified version
+// also keeps the likelihood score equations and weights the stacked
+// moments by the inverse of their analytic covariance at the MLE.
+func CBPSScores(data *CausalData, opts CBPSOptions) ([]float64, error) {
+	if err := requireBothArms(data); err != nil {
+		return nil, err
+	}
+	x := withIntercept(covariateRows(data))
+	t := make([]float64, len(x))
+	for i, v := range data.Treatment {
+		t[i] = float64(v)
+	}
+	p := len(x[0])
+
+	start, err := fitLogistic(x, t)
+	if err != nil {
+		return nil, err
+	}
+
+	var objective func([]float64) float64
+	if opts.Method == CBPSOver {
+		weight, err := cbpsWeightMatrix(x, start)
+		if err != nil {
+			return nil, err
+		}
+		objective = func(beta []float64) float64 {
+			g := cbpsMoments(x, t, beta)
+			var q float64
+			for a := range g {
+				q += g[a] * dot(weight[a], g)
+			}
+			return q
+		}
+	} else {
+		objective = func(beta []float64) float64 {
+			g := cbpsMoments(x, t, beta)[p:]
+			return dot(g, g)
+		}
+	}
+
+	beta, err := minimizeBFGS(objective, start)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(x))
+	for i, row := range x {
+		scores[i] = sigmoid(dot(row, beta))
+	}
+	return scores, nil
+}
+
+// cbpsWeightMatrix returns the inverse of the covariance of the stacked
+// score and balance moments, evaluated at beta
+func cbpsWeightMatrix(x [][]float64, beta []float64) ([][]float64, error) {
+	n, p := float64(len(x)), len(beta)
+	sigma := make([][]float64, 2*p)
+	for a := range sigma {
+		sigma[a] = make([]float64, 2*p)
+	}
+	for _, row := range x {
+		e := boundProbability(sigmoid(dot(row, beta)), 1e-8)
+		v := e * (1 - e)
+		for a := 0; a < p; a++ {
+			for b := 0; b < p; b++ {
+				xx := row[a] * row[b] / n
+				sigma[a][b] += v * xx
+				sigma[a][p+b] += xx
+				sigma[p+a][b] += xx
+				sigma[p+a][p+b] += xx / v
+			}
+		}
+	}
+	return invertMatrix(sigma)
+}
+
+// cbpsMoments returns the stacked mean score and balance moments at beta
+func cbpsMoments(x [][]float64, t, beta []float64) []float64 {
+	p := len(beta)
+	g := make([]float64, 2*p)
+	n := float64(len(x))
+	for i, row := range x {
+		e := boundProbability(sigmoid(dot(row, beta)), 1e-8)
+		score := t[i] - e
+		balance := t[i]/e - (1-t[i])/(1-e)
+		for j, v := range row {
+			g[j] += score * v / n
+			g[p+j] += balance * v / n
+		}
+	}
+	return g
+}
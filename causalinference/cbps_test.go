@@ -0,0 +1,65 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCBPSImprovesBalance(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	// weightedGap returns the imbalance in X after ATE weighting
+	weightedGap := func(scores []float64) float64 {
+		var sum, total [2]float64
+		for i, p := range scores {
+			g := data.Treatment[i]
+			w := balancingWeight(EstimandATE, g, p)
+			sum[g] += w * data.X[i][0]
+			total[g] += w
+		}
+		return math.Abs(sum[1]/total[1] - sum[0]/total[0])
+	}
+
+	cbps, err := CBPSScores(data, CBPSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Exact CBPS balances the weighted means by construction
+	if gap := weightedGap(cbps); gap > 1e-4 {
+		t.Errorf("CBPS imbalance %.6f, want ~0", gap)
+	}
+
+	over, err := CBPSScores(data, CBPSOptions{Method: CBPSOver})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range over {
+		if p <= 0 || p >= 1 {
+			t.Fatalf("over-identified score %d is %.4f", i, p)
+		}
+	}
+
+	res, err := EstimateWeighted(data, WeightingOptions{Propensity: PropensityCBPS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mle, _ := EstimateIPW(data)
+	if math.Abs(res.Estimate-data.TrueEffect) >= math.Abs(mle.Estimate-data.TrueEffect) {
+		t.Errorf("CBPS-weighted estimate %.4f no better than MLE IPW", res.Estimate)
+	}
+}
+
+func TestMinimizeBFGS(t *testing.T) {
+	// Rosenbrock function with minimum at (1, 1)
+	f := func(x []float64) float64 {
+		a, b := 1-x[0], x[1]-x[0]*x[0]
+		return a*a + 100*b*b
+	}
+	x, err := minimizeBFGS(f, []float64{-1.2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x[0]-1) > 1e-3 || math.Abs(x[1]-1) > 1e-3 {
+		t.Errorf("minimum at %v, want [1 1]", x)
+	}
+}
@@ -0,0 +1,63 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestConformalITE(t *testing.T) {
+	// Heterogeneous, noisy individual effects under confounded treatment
+	rng := rand.New(rand.NewSource(3))
+	n := 4000
+	data := &CausalData{X: make([][]float64, n), Treatment: make([]int, n), Outcome: make([]float64, n)}
+	ite := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x := rng.NormFloat64()
+		y0 := x + rng.NormFloat64()
+		y1 := y0 + 2 + x + 0.5*rng.NormFloat64()
+		ite[i] = y1 - y0
+		data.X[i] = []float64{x}
+		if rng.Float64() < sigmoid(x) {
+			data.Treatment[i] = 1
+			data.Outcome[i] = y1
+		} else {
+			data.Outcome[i] = y0
+		}
+	}
+
+	res, err := ConformalITE(data, ConformalOptions{Alpha: 0.1, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var covered, finite int
+	for i := range ite {
+		if res.Lower[i] <= ite[i] && ite[i] <= res.Upper[i] {
+			covered++
+		}
+		if !math.IsInf(res.Upper[i], 1) {
+			finite++
+		}
+	}
+	// The union bound makes the intervals conservative
+	if c := float64(covered) / float64(n); c < 0.9 {
+		t.Errorf("coverage = %.3f, want at least 0.9", c)
+	}
+	if finite < n*9/10 {
+		t.Errorf("only %d of %d intervals are finite", finite, n)
+	}
+	// Predicted effects track the linear truth 2 + x
+	if rmse := rmseAgainst(res.CATE, data.Column(0)); rmse > 0.3 {
+		t.Errorf("CATE RMSE = %.3f", rmse)
+	}
+}
+
+// rmseAgainst is the RMSE of cate against 2 + x
+func rmseAgainst(cate, x []float64) float64 {
+	var ss float64
+	for i, c := range cate {
+		d := c - (2 + x[i])
+		ss += d * d
+	}
+	return math.Sqrt(ss / float64(len(cate)))
+}
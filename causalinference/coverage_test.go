@@ -0,0 +1,29 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimulateCoverage(t *testing.T) {
+	res, err := SimulateCoverage(regressionEstimator, 500, CoverageOptions{Replications: 200, Seed: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The linear outcome model is correct, so the intervals are calibrated
+	if math.Abs(res.Coverage-0.95) > 4*res.MCSE || math.Abs(res.Bias) > 0.03 || res.Failed != 0 {
+		t.Errorf("regression adjustment coverage %+v", res)
+	}
+	if math.Abs(res.MeanSE/res.SD-1) > 0.2 || res.RMSE < res.SD*0.9 {
+		t.Errorf("SE %.4f does not match the sampling SD %.4f", res.MeanSE, res.SD)
+	}
+
+	// The unadjusted comparison is confounded and its intervals miss
+	naive, err := SimulateCoverage(EstimateCausalEffect, 500, CoverageOptions{Replications: 50, Seed: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if naive.Coverage > 0.1 || naive.Bias < 0.5 {
+		t.Errorf("naive coverage %+v", naive)
+	}
+}
@@ -0,0 +1,564 @@
+package causalinference
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// DGPConfig holds the parameters of the data generating process behind
+// GenerateCausalData. Units draw X ~ N(0, 1), are treated with probability
+// BaselinePropensity + 0.5 * Confounding * X (clipped to [0, 1] by the
+// comparison with a uniform draw), and have outcome
+// Y = Confounding * X + Effect * T + NoiseSD * N(0, 1), with X replaced by
+// a transform of it when Nonlinearity is set and a hidden confounder added
+// to both when HiddenConfounding is set. An instrument, when requested,
+// shifts only the propensity, and cluster random effects shift both.
+type DGPConfig struct {
+	Effect             float64 // true treatment effect
+	NoiseSD            float64 // standard deviation of the outcome noise
+	Confounding        float64 // strength of X in both the propensity and the outcome; 0 gives a randomized experiment
+	BaselinePropensity float64 // probability of treatment at X = 0
+	// Nonlinearity replaces X by a nonlinear transform of it in both the
+	// propensity and the outcome; the zero value keeps them linear
+	Nonlinearity Nonlinearity
+	// EffectFunc gives the effect of a unit with covariates x; nil means
+	// the constant Effect
+	EffectFunc func(x []float64) float64
+	Family     OutcomeFamily // outcome distribution; defaults to Gaussian
+	// Baseline is the outcome level of a control unit at X = 0 for
+	// non-Gaussian families: P(Y = 1) for binary outcomes and the mean
+	// count for count outcomes
+	Baseline float64
+	// Dispersion makes count outcomes negative binomial with variance
+	// mu + Dispersion * mu^2; 0 means Poisson
+	Dispersion float64
+	// HiddenConfounding is the strength of an unmeasured confounder
+	// U ~ N(0, 1), which enters the propensity and the outcome like X but
+	// is left out of the data, so no adjustment for X removes its bias
+	HiddenConfounding float64
+	// InstrumentStrength adds a binary instrument Z ~ Bernoulli(0.5) that
+	// raises the probability of treatment by InstrumentStrength and does
+	// not affect the outcome; it is stored in Instrument
+	InstrumentStrength float64
+	// Clusters splits the units evenly into this many clusters, stored in
+	// Cluster; 0 means independent units
+	Clusters int
+	// ClusterOutcomeSD and ClusterTreatmentSD are the standard deviations
+	// of cluster random effects on the outcome and on the propensity,
+	// which make outcomes and treatment correlated within clusters
+	ClusterOutcomeSD   float64
+	ClusterTreatmentSD float64
+	// Noise is the distribution of the Gaussian-family outcome noise,
+	// scaled to mean zero and standard deviation NoiseSD; defaults to normal
+	Noise   NoiseDistribution
+	NoiseDF float64 // degrees of freedom of Student-t noise
+	// NoiseSlope makes the noise heteroskedastic in X: a unit's noise SD
+	// is NoiseSD * exp(NoiseSlope * X); 0 keeps it constant
+	NoiseSlope float64
+	// TreatedNoiseRatio is the noise SD of treated units relative to
+	// controls; 0 means 1
+	TreatedNoiseRatio float64
+	// Covariance draws several covariates X ~ N(0, Covariance) instead of
+	// one standard normal X; the propensity and outcome then depend on
+	// the confounding index Loadings . X in place of X. It must be
+	// positive definite, or GenerateCausalData panics.
+	Covariance [][]float64
+	// Loadings weight the covariates in the confounding index; nil means
+	// equal weights. The index is rescaled to unit variance so that
+	// Confounding keeps its meaning.
+	Loadings []float64
+	// Categorical adds factor covariates after the continuous ones, one-hot
+	// encoded against their first level
+	Categorical []CategoricalCovariate
+	// PropensityFunc gives the probability of treatment of a unit with
+	// covariates x, replacing BaselinePropensity and Confounding in the
+	// treatment model; nil keeps the built-in propensity
+	PropensityFunc func(x []float64) float64
+	// OutcomeFunc gives the mean outcome of a unit with covariates x under
+	// treatment t, replacing the built-in outcome mean and the effect; for
+	// binary outcomes it is P(Y = 1) and for counts the mean count. Each
+	// unit's effect is OutcomeFunc(x, 1) - OutcomeFunc(x, 0).
+	OutcomeFunc func(x []float64, t int) float64
+	// Rand is the source of the random draws; nil means a source local to
+	// the call, seeded with the seed argument
+	Rand *rand.Rand
+}
+
+// CategoricalCovariate describes a factor covariate of the data generating
+// process. A two-level factor is a binary covariate.
+type CategoricalCovariate struct {
+	Name          string    // column name prefix; empty means C1, C2, ... by position
+	Probabilities []float64 // probability of each level, summing to one
+	// Effects shift the confounding index of units at each level, so they
+	// enter both the propensity and the outcome scaled by Confounding;
+	// nil means the factor is not a confounder
+	Effects []float64
+}
+
+// draw returns a level chosen with the covariate's probabilities
+func (c CategoricalCovariate) draw(u float64) int {
+	for k, p := range c.Probabilities {
+		if u < p {
+			return k
+		}
+		u -= p
+	}
+	return len(c.Probabilities) - 1
+}
+
+// OneHot encodes level k of a factor with the given number of levels as
+// levels-1 indicator columns, the first level being the reference as in
+// R's treatment contrasts
+func OneHot(k, levels int) []float64 {
+	row := make([]float64, levels-1)
+	if k > 0 {
+		row[k-1] = 1
+	}
+	return row
+}
+
+// NoiseDistribution selects the shape of the outcome noise
+type NoiseDistribution int
+
+const (
+	// NoiseNormal draws standard normal noise
+	NoiseNormal NoiseDistribution = iota
+	// NoiseStudentT draws heavy-tailed Student-t noise with NoiseDF
+	// degrees of freedom, rescaled to unit variance when NoiseDF > 2
+	NoiseStudentT
+	// NoiseLognormal draws right-skewed noise, exp(Z) centred and scaled
+	NoiseLognormal
+	// NoiseLaplace draws double exponential noise, the heavy-tailed
+	// errors under which median regression is efficient
+	NoiseLaplace
+)
+
+// draw returns one noise value with mean zero and, where it exists, unit
+// variance
+func (k NoiseDistribution) draw(rng *rand.Rand, df float64) float64 {
+	switch k {
+	case NoiseStudentT:
+		t := rng.NormFloat64() / math.Sqrt(2*gammaVariate(rng, df/2)/df)
+		if df > 2 {
+			t *= math.Sqrt((df - 2) / df)
+		}
+		return t
+	case NoiseLognormal:
+		return (math.Exp(rng.NormFloat64()) - math.Exp(0.5)) / math.Sqrt((math.E-1)*math.E)
+	case NoiseLaplace:
+		return rng.ExpFloat64() * float64(2*rng.Intn(2)-1) / math.Sqrt2
+	default:
+		return rng.NormFloat64()
+	}
+}
+
+// OutcomeFamily selects the distribution of the generated outcome
+type OutcomeFamily int
+
+const (
+	// FamilyGaussian adds NoiseSD * N(0, 1) noise to a linear mean
+	FamilyGaussian OutcomeFamily = iota
+	// FamilyBinary draws Y from a logistic model,
+	// P(Y = 1) = logistic(logit(Baseline) + Confounding * X + Effect * T),
+	// so the effect is a log odds ratio
+	FamilyBinary
+	// FamilyCount draws Y from a Poisson or negative binomial model with
+	// mean Baseline * exp(Confounding * X + Effect * T), so the effect is
+	// a log rate ratio and multiplies the mean
+	FamilyCount
+)
+
+// Nonlinearity selects how confounding depends on X. Each transform has
+// mean zero under X ~ N(0, 1) and unit scale, so Confounding keeps its
+// meaning, but estimators that adjust linearly for X are misspecified.
+type Nonlinearity int
+
+const (
+	// NonlinearNone uses X itself
+	NonlinearNone Nonlinearity = iota
+	// NonlinearQuadratic uses (X^2 - 1)/sqrt(2), so both tails are confounded alike
+	NonlinearQuadratic
+	// NonlinearSine uses sqrt(2) sin(pi X / 2)
+	NonlinearSine
+	// NonlinearStep uses the sign of X, a jump at zero
+	NonlinearStep
+)
+
+// apply evaluates the transform at x
+func (k Nonlinearity) apply(x float64) float64 {
+	switch k {
+	case NonlinearQuadratic:
+		return (x*x - 1) / math.Sqrt2
+	case NonlinearSine:
+		return math.Sqrt2 * math.Sin(math.Pi*x/2)
+	case NonlinearStep:
+		if x < 0 {
+			return -1
+		}
+		return 1
+	default:
+		return x
+	}
+}
+
+// DefaultDGPConfig returns the parameters GenerateCausalData uses without
+// options: an effect of 5, unit noise and confounding, and a baseline
+// propensity of 0.5
+func DefaultDGPConfig() DGPConfig {
+	return DGPConfig{
+		Effect:             5,
+		NoiseSD:            1,
+		Confounding:        1,
+		BaselinePropensity: 0.5,
+	}
+}
+
+// Option changes one parameter of the data generating process
+type Option func(*DGPConfig)
+
+// WithEffect sets the true treatment effect
+func WithEffect(effect float64) Option {
+	return func(c *DGPConfig) { c.Effect = effect }
+}
+
+// WithNoiseSD sets the standard deviation of the outcome noise
+func WithNoiseSD(sd float64) Option {
+	return func(c *DGPConfig) { c.NoiseSD = sd }
+}
+
+// WithConfounding sets how strongly X drives both treatment and outcome
+func WithConfounding(strength float64) Option {
+	return func(c *DGPConfig) { c.Confounding = strength }
+}
+
+// WithBaselinePropensity sets the probability of treatment at X = 0
+func WithBaselinePropensity(p float64) Option {
+	return func(c *DGPConfig) { c.BaselinePropensity = p }
+}
+
+// WithNonlinearity makes the propensity and the outcome depend on X
+// through a nonlinear transform
+func WithNonlinearity(kind Nonlinearity) Option {
+	return func(c *DGPConfig) { c.Nonlinearity = kind }
+}
+
+// WithLinearEffect makes the effect vary with the first covariate as
+// a + b*x
+func WithLinearEffect(a, b float64) Option {
+	return func(c *DGPConfig) {
+		c.EffectFunc = func(x []float64) float64 { return a + b*x[0] }
+	}
+}
+
+// WithEffectFunction sets each unit's effect to f of its covariates
+func WithEffectFunction(f func(x []float64) float64) Option {
+	return func(c *DGPConfig) { c.EffectFunc = f }
+}
+
+// WithBinaryOutcome draws 0/1 outcomes from a logistic model in which
+// the effect is a log odds ratio and a control unit at X = 0 has outcome
+// probability baseline
+func WithBinaryOutcome(baseline float64) Option {
+	return func(c *DGPConfig) {
+		c.Family = FamilyBinary
+		c.Baseline = baseline
+	}
+}
+
+// WithHiddenConfounding adds an unobserved confounder of the given
+// strength, to check that sensitivity analyses and refuters flag it
+func WithHiddenConfounding(strength float64) Option {
+	return func(c *DGPConfig) { c.HiddenConfounding = strength }
+}
+
+// WithInstrument generates a valid binary instrument whose treatment
+// probabilities differ by strength between Z = 1 and Z = 0
+func WithInstrument(strength float64) Option {
+	return func(c *DGPConfig) { c.InstrumentStrength = strength }
+}
+
+// WithClusters groups the units into k clusters with random effects of
+// standard deviation outcomeSD on the outcome and treatmentSD on the
+// propensity
+func WithClusters(k int, outcomeSD, treatmentSD float64) Option {
+	return func(c *DGPConfig) {
+		c.Clusters = k
+		c.ClusterOutcomeSD = outcomeSD
+		c.ClusterTreatmentSD = treatmentSD
+	}
+}
+
+// WithNoise sets the distribution of the outcome noise; df is used only
+// by Student-t noise
+func WithNoise(kind NoiseDistribution, df float64) Option {
+	return func(c *DGPConfig) {
+		c.Noise = kind
+		c.NoiseDF = df
+	}
+}
+
+// WithHeteroskedasticity makes the noise SD depend on X through
+// exp(slope * X) and on treatment through the treated-to-control ratio
+// treatedRatio, the settings in which classical OLS standard errors fail
+func WithHeteroskedasticity(slope, treatedRatio float64) Option {
+	return func(c *DGPConfig) {
+		c.NoiseSlope = slope
+		c.TreatedNoiseRatio = treatedRatio
+	}
+}
+
+// WithCovariance draws correlated normal covariates with the given
+// covariance matrix, confounding through the index loadings . X; nil
+// loadings weight the covariates equally. GenerateCausalData panics if
+// the matrix is not positive definite.
+func WithCovariance(covariance [][]float64, loadings []float64) Option {
+	return func(c *DGPConfig) {
+		c.Covariance = covariance
+		c.Loadings = loadings
+	}
+}
+
+// WithCategorical adds a factor covariate with the given level
+// probabilities and confounding effects to the data
+func WithCategorical(name string, probabilities, effects []float64) Option {
+	return func(c *DGPConfig) {
+		c.Categorical = append(c.Categorical, CategoricalCovariate{Name: name, Probabilities: probabilities, Effects: effects})
+	}
+}
+
+// WithPropensityFunction sets each unit's probability of treatment to p
+// of its covariates
+func WithPropensityFunction(p func(x []float64) float64) Option {
+	return func(c *DGPConfig) { c.PropensityFunc = p }
+}
+
+// WithOutcomeFunction sets each unit's mean outcome under treatment t to
+// m of its covariates and t
+func WithOutcomeFunction(m func(x []float64, t int) float64) Option {
+	return func(c *DGPConfig) { c.OutcomeFunc = m }
+}
+
+// WithRand draws from r instead of a source seeded with the seed
+// argument, to continue an existing stream. A *rand.Rand is not safe for
+// concurrent use, so share one only between sequential calls.
+func WithRand(r *rand.Rand) Option {
+	return func(c *DGPConfig) { c.Rand = r }
+}
+
+// WithCountOutcome draws count outcomes with a multiplicative effect:
+// Effect is the log rate ratio, a control unit at X = 0 has mean count
+// baseline, and dispersion 0 gives Poisson counts while a positive value
+// gives negative binomial counts with variance mu + dispersion * mu^2
+func WithCountOutcome(baseline, dispersion float64) Option {
+	return func(c *DGPConfig) {
+		c.Family = FamilyCount
+		c.Baseline = baseline
+		c.Dispersion = dispersion
+	}
+}
+
+// indexLoadings scales loadings, or equal weights when nil, so that the
+// index loadings . X has unit variance under the covariance
+func indexLoadings(covariance [][]float64, loadings []float64) []float64 {
+	w := make([]float64, len(covariance))
+	for j := range w {
+		w[j] = 1
+		if loadings != nil {
+			w[j] = loadings[j]
+		}
+	}
+	scale := math.Sqrt(dot(w, matVec(covariance, w)))
+	for j := range w {
+		w[j] /= scale
+	}
+	return w
+}
+
+// categoricalNames names the continuous covariates X or X1, X2, ... and
+// each indicator column after its factor and level, such as Region2
+func categoricalNames(cfg DGPConfig) []string {
+	continuous := []string{"X"}
+	if cfg.Covariance != nil {
+		continuous = make([]string, len(cfg.Covariance))
+		for j := range continuous {
+			continuous[j] = fmt.Sprintf("X%d", j+1)
+		}
+	}
+	names := continuous
+	for j, c := range cfg.Categorical {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("C%d", j+1)
+		}
+		for k := 1; k < len(c.Probabilities); k++ {
+			names = append(names, fmt.Sprintf("%s%d", name, k))
+		}
+	}
+	return names
+}
+
+// GenerateCausalData creates synthetic data from DefaultDGPConfig changed
+// by the given options, so simulations can sweep the effect size, noise
+// and confounding. Each unit's true effect is kept in UnitEffects and
+// their average, the sample ATE, in TrueEffect. Both are differences in
+// expected outcomes, so for binary outcomes they are risk differences
+// rather than the log odds ratio Effect, and for count outcomes
+// differences in mean counts rather than the log rate ratio. Draws come
+// from a source local to the call unless WithRand supplies one, so the
+// global math/rand state is untouched and concurrent calls with the same
+// seed return the same data.
+func GenerateCausalData(n int, seed int64, opts ...Option) *CausalData {
+	cfg := DefaultDGPConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	rng := cfg.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(seed))
+	}
+
+	data := &CausalData{
+		X:           make([][]float64, n),
+		Treatment:   make([]int, n),
+		Outcome:     make([]float64, n),
+		UnitEffects: make([]float64, n),
+		TrueEffect:  cfg.Effect,
+	}
+	if cfg.InstrumentStrength != 0 {
+		data.Instrument = make([]float64, n)
+	}
+
+	// Cluster random effects on the outcome and the propensity
+	var clusterOutcome, clusterTreatment []float64
+	if cfg.Clusters > 0 {
+		data.Cluster = make([]int, n)
+		clusterOutcome = make([]float64, cfg.Clusters)
+		clusterTreatment = make([]float64, cfg.Clusters)
+		for c := range clusterOutcome {
+			clusterOutcome[c] = cfg.ClusterOutcomeSD * rng.NormFloat64()
+			clusterTreatment[c] = cfg.ClusterTreatmentSD * rng.NormFloat64()
+		}
+	}
+
+	// Correlated covariates are L z for the Cholesky factor L of the
+	// covariance and standard normal z
+	var factor [][]float64
+	var loadings []float64
+	if cfg.Covariance != nil {
+		var err error
+		if factor, err = cholesky(cfg.Covariance); err != nil {
+			panic("causalinference: DGP covariance matrix is not positive definite")
+		}
+		loadings = indexLoadings(cfg.Covariance, cfg.Loadings)
+	}
+
+	for i := 0; i < n; i++ {
+		// Generate basic data
+		x := rng.NormFloat64()
+		data.X[i] = []float64{x}
+		if factor != nil {
+			z := make([]float64, len(factor))
+			z[0] = x
+			for j := 1; j < len(z); j++ {
+				z[j] = rng.NormFloat64()
+			}
+			data.X[i] = matVec(factor, z)
+			x = dot(loadings, data.X[i])
+		}
+
+		// Treatment is more likely for higher X values
+		g := cfg.Nonlinearity.apply(x)
+		for _, c := range cfg.Categorical {
+			k := c.draw(rng.Float64())
+			if c.Effects != nil {
+				g += c.Effects[k]
+			}
+			data.X[i] = append(data.X[i], OneHot(k, len(c.Probabilities))...)
+		}
+		var u float64
+		if cfg.HiddenConfounding != 0 {
+			// Drawn only when used, so other settings keep their sequence
+			u = cfg.HiddenConfounding * rng.NormFloat64()
+		}
+		var b float64
+		p := cfg.BaselinePropensity + 0.5*(cfg.Confounding*g+u)
+		if cfg.PropensityFunc != nil {
+			p = cfg.PropensityFunc(data.X[i]) + 0.5*u
+		}
+		if data.Cluster != nil {
+			data.Cluster[i] = i % cfg.Clusters
+			b = clusterOutcome[data.Cluster[i]]
+			p += 0.5 * clusterTreatment[data.Cluster[i]]
+		}
+		if data.Instrument != nil {
+			if rng.Float64() < 0.5 {
+				data.Instrument[i] = 1
+			}
+			p += cfg.InstrumentStrength * (data.Instrument[i] - 0.5)
+		}
+		if rng.Float64() < p {
+			data.Treatment[i] = 1
+		}
+
+		effect := cfg.Effect
+		if cfg.EffectFunc != nil {
+			effect = cfg.EffectFunc(data.X[i])
+		}
+		data.UnitEffects[i] = effect
+
+		// Outcome depends on X and treatment through its mean
+		t := float64(data.Treatment[i])
+		var mean float64
+		switch {
+		case cfg.OutcomeFunc != nil:
+			m1, m0 := cfg.OutcomeFunc(data.X[i], 1), cfg.OutcomeFunc(data.X[i], 0)
+			data.UnitEffects[i] = m1 - m0
+			mean = m0 + t*(m1-m0)
+			if cfg.Family == FamilyGaussian {
+				mean += u + b
+			}
+		case cfg.Family == FamilyBinary:
+			lp := logit(cfg.Baseline) + cfg.Confounding*g + u + b
+			data.UnitEffects[i] = sigmoid(lp+effect) - sigmoid(lp)
+			mean = sigmoid(lp + effect*t)
+		case cfg.Family == FamilyCount:
+			mu := cfg.Baseline * math.Exp(cfg.Confounding*g+u+b)
+			data.UnitEffects[i] = mu * (math.Exp(effect) - 1)
+			mean = mu * math.Exp(effect*t)
+		default:
+			mean = cfg.Confounding*g + u + b + t*effect
+		}
+
+		switch cfg.Family {
+		case FamilyBinary:
+			if rng.Float64() < mean {
+				data.Outcome[i] = 1
+			}
+		case FamilyCount:
+			if cfg.Dispersion > 0 {
+				// Gamma frailty with mean 1 and variance Dispersion
+				mean *= gammaVariate(rng, 1/cfg.Dispersion) * cfg.Dispersion
+			}
+			data.Outcome[i] = float64(poissonVariate(rng, mean))
+		default:
+			sd := cfg.NoiseSD
+			if cfg.NoiseSlope != 0 {
+				sd *= math.Exp(cfg.NoiseSlope * x)
+			}
+			if cfg.TreatedNoiseRatio > 0 && data.Treatment[i] == 1 {
+				sd *= cfg.TreatedNoiseRatio
+			}
+			data.Outcome[i] = mean + sd*cfg.Noise.draw(rng, cfg.NoiseDF)
+		}
+	}
+	if cfg.Categorical != nil {
+		data.Names = categoricalNames(cfg)
+	}
+	if cfg.EffectFunc != nil || cfg.OutcomeFunc != nil || cfg.Family != FamilyGaussian {
+		data.TrueEffect, _ = meanAndSE(data.UnitEffects)
+	}
+
+	return data
+}
@@ -0,0 +1,57 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEstimateDiD(t *testing.T) {
+	data := GenerateDiDData(5000, 42)
+
+	res, err := EstimateDiD(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.15 {
+		t.Errorf("DiD estimate %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+
+	// The interaction coefficient equals the difference in mean changes
+	var changes [2]float64
+	var counts [2]float64
+	for i, g := range data.Treated {
+		changes[g] += data.Post[i] - data.Pre[i]
+		counts[g]++
+	}
+	want := changes[1]/counts[1] - changes[0]/counts[0]
+	if math.Abs(res.Estimate-want) > 1e-9 {
+		t.Errorf("estimate %.6f != difference in mean changes %.6f", res.Estimate, want)
+	}
+}
+
+func TestDiDClustered(t *testing.T) {
+	// Treatment assigned by state, with a state-level shock to the change
+	data := GenerateDiDData(2000, 3)
+	rng := rand.New(rand.NewSource(3))
+	shocks := make([]float64, 20)
+	for s := range shocks {
+		shocks[s] = rng.NormFloat64()
+	}
+	data.Cluster = make([]int, len(data.Treated))
+	for i := range data.Treated {
+		s := rng.Intn(10)*2 + data.Treated[i]
+		data.Cluster[i] = s
+		data.Post[i] += shocks[s]
+	}
+
+	clustered, err := EstimateDiD(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Cluster = nil
+	plain, _ := EstimateDiD(data)
+	if clustered.Estimate != plain.Estimate || clustered.SE < 3*plain.SE {
+		t.Errorf("clustered SE %.4f, unclustered %.4f", clustered.SE, plain.SE)
+	}
+}
@@ -0,0 +1,117 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+)
+
+// DMLOptions configures double machine learning
+type DMLOptions struct {
+	Folds            int     // cross-fitting folds; 0 means 5
+	OutcomeLearner   Learner // model for E[Y|X]; defaults to LinearLearner
+	TreatmentLearner Learner // model for E[T|X]; defaults to LogisticLearner
+	Seed             int64   // seed for fold assignment
+}
+
+// EstimateDML estimates the effect in the partially linear model
+// Y = theta*T + g(X) + e with the partialling-out score of Chernozhukov et
+// al. (2018). Both nuisance functions are fit with K-fold cross-fitting, so
+// each unit's residuals come from models that never saw it. The standard
+// error is computed from the Neyman-orthogonal score, as DoubleML reports,
+// with the score's influence function summed within clusters when Cluster
+// is set.
+func EstimateDML(data *CausalData, opts DMLOptions) (EffectResult, error) {
+	k := opts.Folds
+	if k < 2 {
+		k = 5
+	}
+	outcome := opts.OutcomeLearner
+	if outcome == nil {
+		outcome = LinearLearner{}
+	}
+	treatment := opts.TreatmentLearner
+	if treatment == nil {
+		treatment = LogisticLearner{}
+	}
+
+	rows := covariateRows(data)
+	n := len(rows)
+	d := make([]float64, n)
+	for i, t := range data.Treatment {
+		d[i] = float64(t)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	folds := foldAssignments(n, k, rng)
+
+	yRes, err := crossFitResiduals(outcome, rows, data.Outcome, folds, k)
+	if err != nil {
+		return EffectResult{}, err
+	}
+	dRes, err := crossFitResiduals(treatment, rows, d, folds, k)
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	// Solve the orthogonal moment condition for theta
+	var num, den float64
+	for i := range yRes {
+		num += dRes[i] * yRes[i]
+		den += dRes[i] * dRes[i]
+	}
+	if den == 0 {
+		return EffectResult{}, ErrEmptyArm
+	}
+	theta := num / den
+
+	// The influence function is the score over its mean derivative j
+	j := den / float64(n)
+	ic := make([]float64, n)
+	var psi2 float64
+	for i := range yRes {
+		psi := (yRes[i] - theta*dRes[i]) * dRes[i]
+		psi2 += psi * psi
+		ic[i] = psi / j
+	}
+	se := math.Sqrt(psi2/float64(n)) / j / math.Sqrt(float64(n))
+	if data.Cluster != nil {
+		se = influenceSE(ic, data.Cluster)
+	}
+
+	return newEffectResult("DML", theta, se, n), nil
+}
+
+// foldAssignments randomly assigns n units to k folds of near-equal size
+func foldAssignments(n, k int, rng *rand.Rand) []int {
+	folds := make([]int, n)
+	for pos, i := range rng.Perm(n) {
+		folds[i] = pos % k
+	}
+	return folds
+}
+
+// crossFitResiduals returns y minus out-of-fold predictions from learner
+func crossFitResiduals(learner Learner, x [][]float64, y []float64, folds []int, k int) ([]float64, error) {
+	resid := make([]float64, len(y))
+	for f := 0; f < k; f++ {
+		var trainX [][]float64
+		var trainY []float64
+		for i, g := range folds {
+			if g != f {
+				trainX = append(trainX, x[i])
+				trainY = append(trainY, y[i])
+			}
+		}
+
+		model, err := learner.Fit(trainX, trainY)
+		if err != nil {
+			return nil, err
+		}
+		for i, g := range folds {
+			if g == f {
+				resid[i] = y[i] - model.Predict(x[i])
+			}
+		}
+	}
+	return resid, nil
+}
@@ -0,0 +1,27 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateDML(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	res, err := EstimateDML(data, DMLOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.15 {
+		t.Errorf("DML estimate %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+
+	// A custom learner is used for both nuisance functions
+	res2, err := EstimateDML(data, DMLOptions{Seed: 1, Folds: 3, OutcomeLearner: LinearLearner{}, TreatmentLearner: LinearLearner{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res2.Estimate-data.TrueEffect) > 0.15 {
+		t.Errorf("DML with linear learners %.4f too far from %.1f", res2.Estimate, data.TrueEffect)
+	}
+}
@@ -0,0 +1,54 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDoseResponseGPS(t *testing.T) {
+	data := GenerateDoseResponseData(5000, 123)
+
+	res, err := EstimateDoseResponse(data, DoseResponseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Grid) != 10 || len(res.Curve) != 10 {
+		t.Fatalf("got %d grid points and %d curve values, want 10", len(res.Grid), len(res.Curve))
+	}
+
+	// Regressing Y on the dose alone picks up the confounding through X
+	naive, _ := fitOLS(withIntercept(covariateRows(&CausalData{X: CovariateMatrix(data.Dose)})), data.Outcome)
+
+	var gpsErr, naiveErr float64
+	for k, d := range res.Grid {
+		truth := data.TrueDoseResponse(d)
+		gpsErr = math.Max(gpsErr, math.Abs(res.Curve[k]-truth))
+		naiveErr = math.Max(naiveErr, math.Abs(naive[0]+naive[1]*d-truth))
+	}
+	if gpsErr > 0.25 {
+		t.Errorf("max curve error %.4f, want below 0.25", gpsErr)
+	}
+	if gpsErr >= naiveErr {
+		t.Errorf("GPS error %.4f no better than naive %.4f", gpsErr, naiveErr)
+	}
+	for k, se := range res.SE {
+		if se <= 0 {
+			t.Errorf("grid point %d: SE %.4f", k, se)
+		}
+	}
+
+	// The regression form runs on the same grid without standard errors
+	hi, err := EstimateDoseResponse(data, DoseResponseOptions{Method: GPSRegression})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hi.Curve) != len(res.Grid) || hi.SE != nil {
+		t.Errorf("unexpected Hirano-Imbens result %+v", hi)
+	}
+
+	// A caller-supplied grid is used as given
+	res, _ = EstimateDoseResponse(data, DoseResponseOptions{Grid: []float64{0, 1}})
+	if len(res.Curve) != 2 || res.Grid[1] != 1 {
+		t.Errorf("custom grid not respected: %+v", res)
+	}
+}
@@ -0,0 +1,155 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNoConvergence is returned when an iterative solver fails to converge
+var ErrNoConvergence = errors.New("causalinference: solver did not converge")
+
+// Settings for the entropy balancing Newton solver
+const (
+	ebalMaxIter   = 200
+	ebalTolerance = 1e-8
+)
+
+// EntropyBalanceWeights returns ATT weights from entropy balancing
+// (Hainmueller, 2012): treated units get weight 1, and control weights are
+// the maximum-entropy weights whose weighted covariate means exactly equal
+// the treated means. Control weights sum to the number of treated units.
+// The dual problem, minimising log(sum_j exp(l'(c_j - m))) over l, is
+// solved by damped Newton steps.
+func EntropyBalanceWeights(data *CausalData) ([]float64, error) {
+	if err := requireBothArms(data); err != nil {
+		return nil, err
+	}
+	rows := covariateRows(data)
+	p := len(rows[0])
+
+	// Target moments: treated covariate means
+	target := make([]float64, p)
+	var nTreated float64
+	var controls []int
+	for i, row := range rows {
+		if data.Treatment[i] == 1 {
+			for j, v := range row {
+				target[j] += v
+			}
+			nTreated++
+		} else {
+			controls = append(controls, i)
+		}
+	}
+	for j := range target {
+		target[j] /= nTreated
+	}
+
+	// Centre control covariates on the target so the constraint is E_w[c] = 0
+	c := make([][]float64, len(controls))
+	for k, i := range controls {
+		c[k] = make([]float64, p)
+		for j, v := range rows[i] {
+			c[k][j] = v - target[j]
+		}
+	}
+
+	lambda := make([]float64, p)
+	w := ebalWeights(c, lambda)
+	converged := false
+	for iter := 0; iter < ebalMaxIter; iter++ {
+		grad := make([]float64, p)
+		for k, row := range c {
+			for j, v := range row {
+				grad[j] += w[k] * v
+			}
+		}
+		if math.Sqrt(dot(grad, grad)) < ebalTolerance {
+			converged = true
+			break
+		}
+
+		hess := make([][]float64, p)
+		for j := range hess {
+			hess[j] = make([]float64, p)
+		}
+		for k, row := range c {
+			for a := 0; a < p; a++ {
+				for b := 0; b < p; b++ {
+					hess[a][b] += w[k] * (row[a] - grad[a]) * (row[b] - grad[b])
+				}
+			}
+		}
+		step, err := solveLinear(hess, grad)
+		if err != nil {
+			return nil, err
+		}
+
+		// Halve the step until the (convex) dual objective decreases
+		obj := ebalObjective(c, lambda)
+		scale := 1.0
+		next := make([]float64, p)
+		for ; scale > 1e-10; scale /= 2 {
+			for j := range next {
+				next[j] = lambda[j] - scale*step[j]
+			}
+			if ebalObjective(c, next) <= obj {
+				break
+			}
+		}
+		lambda = next
+		w = ebalWeights(c, lambda)
+	}
+	if !converged {
+		return nil, ErrNoConvergence
+	}
+
+	weights := make([]float64, len(rows))
+	for i, t := range data.Treatment {
+		if t == 1 {
+			weights[i] = 1
+		}
+	}
+	for k, i := range controls {
+		weights[i] = w[k] * nTreated
+	}
+	return weights, nil
+}
+
+// ebalWeights returns normalised weights proportional to exp(lambda'c)
+func ebalWeights(c [][]float64, lambda []float64) []float64 {
+	w := make([]float64, len(c))
+	var top float64
+	for k, row := range c {
+		w[k] = dot(lambda, row)
+		if k == 0 || w[k] > top {
+			top = w[k]
+		}
+	}
+	var total float64
+	for k := range w {
+		w[k] = math.Exp(w[k] - top)
+		total += w[k]
+	}
+	for k := range w {
+		w[k] /= total
+	}
+	return w
+}
+
+// ebalObjective is the dual objective log(sum exp(lambda'c))
+func ebalObjective(c [][]float64, lambda []float64) float64 {
+	var top float64
+	z := make([]float64, len(c))
+	for k, row := range c {
+		z[k] = dot(lambda, row)
+		if k == 0 || z[k] > top {
+			top = z[k]
+		}
+	}
+	var total float64
+	for _, v := range z {
+		total += math.Exp(v - top)
+	}
+	return top + math.Log(total)
+}
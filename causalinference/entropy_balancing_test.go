@@ -0,0 +1,45 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEntropyBalanceWeights(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	weights, err := EntropyBalanceWeights(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Weighted control mean of X must equal the treated mean exactly
+	var tSum, tN, cSum, cW float64
+	for i, x := range data.Column(0) {
+		if data.Treatment[i] == 1 {
+			tSum += x
+			tN++
+		} else {
+			cSum += weights[i] * x
+			cW += weights[i]
+		}
+	}
+	if math.Abs(tSum/tN-cSum/cW) > 1e-6 {
+		t.Errorf("treated mean %.6f vs weighted control mean %.6f", tSum/tN, cSum/cW)
+	}
+	if math.Abs(cW-tN) > 1e-6 {
+		t.Errorf("control weights sum to %.3f, want %.0f", cW, tN)
+	}
+
+	// Balancing the covariate removes the linear confounding
+	res, err := EstimateWeighted(data, WeightingOptions{Scheme: WeightEntropy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.2 {
+		t.Errorf("entropy balancing ATT %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+	if _, err := EstimateWeighted(data, WeightingOptions{Scheme: WeightEntropy, Estimand: EstimandATE}); err != ErrUnsupportedEstimand {
+		t.Errorf("expected ErrUnsupportedEstimand, got %v", err)
+	}
+}
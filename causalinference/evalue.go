@@ -0,0 +1,52 @@
+package causalinference
+
+import "math"
+
+// EValue summarizes how strong unmeasured confounding would have to be to
+// explain away an estimate: the minimum risk ratio association an
+// unmeasured confounder would need with both treatment and outcome
+type EValue struct {
+	Point float64 // E-value for the point estimate
+	CI    float64 // E-value for the confidence limit closest to the null; 1 if the interval covers it
+}
+
+// ComputeEValue returns VanderWeele and Ding's (2017) E-value for a risk
+// ratio estimate and its confidence interval. Protective ratios below one
+// are inverted first. For a ratio RR >= 1 the E-value is RR + sqrt(RR(RR-1)).
+func ComputeEValue(effect float64, ci [2]float64) EValue {
+	ev := EValue{Point: riskRatioEValue(effect), CI: 1}
+	switch {
+	case effect >= 1 && ci[0] > 1:
+		ev.CI = riskRatioEValue(ci[0])
+	case effect < 1 && ci[1] < 1:
+		ev.CI = riskRatioEValue(ci[1])
+	}
+	return ev
+}
+
+// riskRatioEValue returns the E-value of a single risk ratio
+func riskRatioEValue(rr float64) float64 {
+	if rr < 1 {
+		rr = 1 / rr
+	}
+	return rr + math.Sqrt(rr*(rr-1))
+}
+
+// EValue returns the E-value of the result. Risk ratios are used as they
+// are and odds ratios through their square root, which approximates a risk
+// ratio for common outcomes. Differences in means are first standardized by
+// outcomeSD, the outcome's standard deviation, and converted with
+// RR = exp(0.91 d); outcomeSD is ignored on the ratio scales.
+func (r EffectResult) EValue(outcomeSD float64) EValue {
+	toRatio := func(v float64) float64 {
+		switch r.Scale {
+		case ScaleRiskRatio:
+			return v
+		case ScaleOddsRatio:
+			return math.Sqrt(v)
+		default:
+			return math.Exp(0.91 * v / outcomeSD)
+		}
+	}
+	return ComputeEValue(toRatio(r.Estimate), [2]float64{toRatio(r.CI[0]), toRatio(r.CI[1])})
+}
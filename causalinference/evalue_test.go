@@ -0,0 +1,42 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeEValue(t *testing.T) {
+	// Worked example from VanderWeele and Ding (2017): RR 3.9 (1.8, 8.7)
+	ev := ComputeEValue(3.9, [2]float64{1.8, 8.7})
+	if math.Abs(ev.Point-7.26) > 0.01 || math.Abs(ev.CI-3.0) > 0.01 {
+		t.Errorf("E-values %.2f and %.2f, want 7.26 and 3.00", ev.Point, ev.CI)
+	}
+
+	// Protective effects are inverted; intervals covering the null give 1
+	if p := ComputeEValue(1/3.9, [2]float64{1 / 8.7, 1 / 1.8}); math.Abs(p.Point-ev.Point) > 1e-9 || math.Abs(p.CI-ev.CI) > 1e-9 {
+		t.Errorf("protective E-values %+v, want %+v", p, ev)
+	}
+	if n := ComputeEValue(1.3, [2]float64{0.9, 1.8}); n.CI != 1 {
+		t.Errorf("CI E-value %.3f for an interval covering 1, want 1", n.CI)
+	}
+}
+
+func TestResultEValue(t *testing.T) {
+	data := binaryOutcomeData(4000, 123)
+	rr, err := EstimateWeighted(data, WeightingOptions{Scale: ScaleRiskRatio})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ComputeEValue(rr.Estimate, rr.CI)
+	if got := rr.EValue(0); got != want {
+		t.Errorf("risk ratio E-value %+v, want %+v", got, want)
+	}
+
+	// A difference in means is standardized by the outcome SD first
+	cont := GenerateCausalData(1000, 1)
+	res, _ := EstimateWeighted(cont, WeightingOptions{})
+	sd := stdDev(cont.Outcome)
+	if got := res.EValue(sd); got.Point <= got.CI || got.CI <= 1 {
+		t.Errorf("unexpected E-values %+v for a clear effect", got)
+	}
+}
@@ -0,0 +1,176 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// GenerateStaggeredData creates a balanced panel with staggered adoption.
+// Units fall evenly into four cohorts: treated from period 3, 5 or 7
+// onward, or never treated, and treatment is absorbing. Earlier cohorts
+// have higher unit effects. The effect grows with time since adoption,
+// 1 + 0.5e at event time e, so a static two-way fixed effects regression
+// is biased. periods should be at least 8.
+func GenerateStaggeredData(units, periods int, seed int64) *PanelData {
+	rng := rand.New(rand.NewSource(seed))
+	cohorts := []int{3, 5, 7, -1}
+
+	data := &PanelData{EventEffects: make([]float64, periods)}
+	for e := range data.EventEffects {
+		data.EventEffects[e] = 1 + 0.5*float64(e)
+	}
+	data.TrueEffect = data.EventEffects[0]
+
+	for u := 0; u < units; u++ {
+		adopt := cohorts[u%len(cohorts)]
+		alpha := rng.NormFloat64()
+		if adopt >= 0 {
+			alpha += 1 - 0.1*float64(adopt)
+		}
+		for t := 0; t < periods; t++ {
+			var tr, effect float64
+			if adopt >= 0 && t >= adopt {
+				tr = 1
+				effect = data.EventEffects[t-adopt]
+			}
+			data.Unit = append(data.Unit, u)
+			data.Time = append(data.Time, t)
+			data.Treatment = append(data.Treatment, tr)
+			data.Outcome = append(data.Outcome, alpha+0.3*float64(t)+effect+rng.NormFloat64())
+		}
+	}
+
+	return data
+}
+
+// EventStudyOptions configures EstimateEventStudy
+type EventStudyOptions struct {
+	Leads int // pre-treatment event times to report, before -1; 0 reports all
+	Lags  int // post-treatment event times to report, after 0; 0 reports all
+}
+
+// EventTimeEffect is the estimated effect at one time relative to adoption
+type EventTimeEffect struct {
+	EffectResult
+	EventTime int // periods since adoption; negative values are leads
+}
+
+// EventStudyResult holds event-study coefficients ordered by event time.
+// Event time -1 is the reference period and is not reported.
+type EventStudyResult struct {
+	Effects []EventTimeEffect
+	Cohorts int // number of treated adoption cohorts
+}
+
+// EstimateEventStudy estimates effects by time relative to treatment
+// adoption with the interaction-weighted estimator of Sun and Abraham
+// (2021), as in fixest's sunab. Adoption is the first period with nonzero
+// treatment and is treated as absorbing. A two-way fixed effects
+// regression includes a dummy for every cohort and event time except -1,
+// using never-treated units as controls, so each coefficient compares one
+// cohort with the never-treated against its own pre-adoption baseline.
+// Effects at each event time average the cohort coefficients weighted by
+// cohort size, with standard errors from the classical covariance.
+// Leads estimate pre-trends and should be near zero.
+func EstimateEventStudy(data *PanelData, opts EventStudyOptions) (EventStudyResult, error) {
+	adoption := make(map[int]int)
+	first := math.MaxInt32
+	for i, u := range data.Unit {
+		if data.Time[i] < first {
+			first = data.Time[i]
+		}
+		if data.Treatment[i] == 0 {
+			continue
+		}
+		if t, ok := adoption[u]; !ok || data.Time[i] < t {
+			adoption[u] = data.Time[i]
+		}
+	}
+
+	// Cohort sizes in units, and at least one never-treated unit as control
+	cohortSize := make(map[int]float64)
+	for _, g := range adoption {
+		if g <= first {
+			return EventStudyResult{}, ErrNoPrePeriods
+		}
+		cohortSize[g]++
+	}
+	if len(cohortSize) == 0 || len(adoption) == countDistinct(data.Unit) {
+		return EventStudyResult{}, ErrEmptyArm
+	}
+
+	// One column per (cohort, event time) pair seen in the data
+	type cell struct{ cohort, event int }
+	column := make(map[cell]int)
+	var cells []cell
+	for i, u := range data.Unit {
+		g, ok := adoption[u]
+		if !ok {
+			continue
+		}
+		c := cell{g, data.Time[i] - g}
+		if _, seen := column[c]; !seen && c.event != -1 {
+			column[c] = len(cells)
+			cells = append(cells, c)
+		}
+	}
+	regressors := make([][]float64, len(cells))
+	for j := range regressors {
+		regressors[j] = make([]float64, len(data.Outcome))
+	}
+	count := make([]int, len(cells))
+	for i, u := range data.Unit {
+		g, ok := adoption[u]
+		if !ok {
+			continue
+		}
+		if j, ok := column[cell{g, data.Time[i] - g}]; ok {
+			regressors[j][i] = 1
+			count[j]++
+		}
+	}
+
+	coef, cov, err := withinFit(data.Unit, data.Time, data.Outcome, regressors)
+	if err != nil {
+		return EventStudyResult{}, err
+	}
+
+	// Aggregate cohort coefficients at each event time by cohort size
+	byEvent := make(map[int][]int)
+	for j, c := range cells {
+		byEvent[c.event] = append(byEvent[c.event], j)
+	}
+	events := make([]int, 0, len(byEvent))
+	for e := range byEvent {
+		if (opts.Leads > 0 && e < -1-opts.Leads) || (opts.Lags > 0 && e > opts.Lags) {
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.Ints(events)
+
+	res := EventStudyResult{Cohorts: len(cohortSize)}
+	for _, e := range events {
+		cols := byEvent[e]
+		var total float64
+		for _, j := range cols {
+			total += cohortSize[cells[j].cohort]
+		}
+		var est, variance float64
+		var n int
+		for _, j := range cols {
+			wj := cohortSize[cells[j].cohort] / total
+			est += wj * coef[j]
+			n += count[j]
+			for _, k := range cols {
+				variance += wj * cohortSize[cells[k].cohort] / total * cov[j][k]
+			}
+		}
+		res.Effects = append(res.Effects, EventTimeEffect{
+			EffectResult: newEffectResult("EventStudy", est, math.Sqrt(variance), n),
+			EventTime:    e,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEventStudyStaggered(t *testing.T) {
+	data := GenerateStaggeredData(400, 10, 123)
+
+	res, err := EstimateEventStudy(data, EventStudyOptions{Leads: 2, Lags: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Cohorts != 3 {
+		t.Errorf("found %d cohorts, want 3", res.Cohorts)
+	}
+
+	// Event times -3, -2, 0, 1, 2 with -1 as the reference
+	want := []int{-3, -2, 0, 1, 2}
+	if len(res.Effects) != len(want) {
+		t.Fatalf("got %d event times, want %d", len(res.Effects), len(want))
+	}
+	for k, e := range res.Effects {
+		if e.EventTime != want[k] {
+			t.Fatalf("event time %d at position %d, want %d", e.EventTime, k, want[k])
+		}
+		truth := 0.0
+		if e.EventTime >= 0 {
+			truth = data.EventEffects[e.EventTime]
+		}
+		if math.Abs(e.Estimate-truth) > 4*e.SE {
+			t.Errorf("event time %d: %.3f (SE %.3f), want %.2f", e.EventTime, e.Estimate, e.SE, truth)
+		}
+	}
+
+	// Static TWFE averages the growing effects into a single biased number
+	static, err := EstimateFixedEffects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(static.Estimate-data.EventEffects[0]) < 0.3 {
+		t.Errorf("static TWFE %.3f unexpectedly close to the instantaneous effect", static.Estimate)
+	}
+}
@@ -0,0 +1,29 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateFrontDoor(t *testing.T) {
+	data := GenerateFrontDoorData(5000, 42)
+
+	res, err := EstimateFrontDoor(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v does not cover %.2f", res.CI, data.TrueEffect)
+	}
+
+	// The naive comparison is biased by the hidden confounder
+	var sums, counts [2]float64
+	for i, tr := range data.Treatment {
+		sums[tr] += data.Outcome[i]
+		counts[tr]++
+	}
+	naive := sums[1]/counts[1] - sums[0]/counts[0]
+	if math.Abs(naive-data.TrueEffect) < math.Abs(res.Estimate-data.TrueEffect) {
+		t.Error("expected the naive difference to be more biased than front-door")
+	}
+}
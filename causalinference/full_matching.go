@@ -0,0 +1,259 @@
+package causalinference
+
+import (
+	"math"
+	"sort"
+)
+
+// FullMatchOptions configures optimal full matching
+type FullMatchOptions struct {
+	Distance Distance // distance measure; defaults to the propensity score
+	Estimand Estimand // target population; defaults to ATT
+}
+
+// MatchFull performs optimal full matching: every unit is placed in a
+// matched set containing either one treated unit and one or more controls
+// or one control and one or more treated units, so that the total
+// treated-control distance within sets is as small as possible, as in
+// optmatch's fullmatch without restrictions.
+// Such a partition is a minimum-weight edge cover of the bipartite
+// treated-control graph. It is found by reducing the cover to a maximum
+// gain matching, solved as an assignment problem with the Hungarian
+// algorithm in O(n^3) time, after which every unit left unmatched joins
+// its nearest neighbor's set.
+func MatchFull(data *CausalData, opts FullMatchOptions) (MatchResult, error) {
+	var treated, controls []int
+	for i, t := range data.Treatment {
+		if t == 1 {
+			treated = append(treated, i)
+		} else {
+			controls = append(controls, i)
+		}
+	}
+	if len(treated) == 0 || len(controls) == 0 {
+		return MatchResult{}, ErrEmptyArm
+	}
+
+	var dist func(i, j int) float64
+	switch opts.Distance {
+	case DistanceMahalanobis:
+		points, err := mahalanobisPoints(covariateRows(data))
+		if err != nil {
+			return MatchResult{}, err
+		}
+		dist = func(i, j int) float64 { return math.Sqrt(squaredDistance(points[i], points[j])) }
+	default:
+		scores, err := propensityScores(data)
+		if err != nil {
+			return MatchResult{}, err
+		}
+		dist = func(i, j int) float64 { return math.Abs(scores[i] - scores[j]) }
+	}
+
+	d := make([][]float64, len(treated))
+	for a, i := range treated {
+		d[a] = make([]float64, len(controls))
+		for b, j := range controls {
+			d[a][b] = dist(i, j)
+		}
+	}
+
+	edges := minimumEdgeCover(d)
+	sets := starSets(edges, treated, controls)
+
+	res, err := matchedEffect(data, sets, opts.Estimand.or(EstimandATT))
+	if err != nil {
+		return MatchResult{}, err
+	}
+	res.Method = "FullMatching"
+	return res, nil
+}
+
+// minimumEdgeCover returns the (treated, control) index pairs of a
+// minimum-weight edge cover of the complete bipartite graph with edge
+// weights d. With m(v) the cheapest edge at vertex v, the cover costs
+// sum m(v) minus the largest total of gains m(u) + m(v) - d(u, v) over a
+// matching, so the matching's edges are kept and every other vertex is
+// covered by its cheapest edge.
+func minimumEdgeCover(d [][]float64) [][2]int {
+	rows, cols := len(d), len(d[0])
+	rowBest := make([]int, rows)
+	colBest := make([]int, cols)
+	for a := range d {
+		for b := range d[a] {
+			if d[a][b] < d[a][rowBest[a]] {
+				rowBest[a] = b
+			}
+			if d[a][b] < d[colBest[b]][b] {
+				colBest[b] = a
+			}
+		}
+	}
+
+	// Minimise negative gains; pairs without a positive gain cost nothing,
+	// which is the same as leaving both ends unmatched
+	cost := make([][]float64, rows)
+	for a := range cost {
+		cost[a] = make([]float64, cols)
+		for b := range cost[a] {
+			gain := d[a][rowBest[a]] + d[colBest[b]][b] - d[a][b]
+			if gain > 0 {
+				cost[a][b] = -gain
+			}
+		}
+	}
+	assigned := hungarian(cost)
+
+	var edges [][2]int
+	rowCovered := make([]bool, rows)
+	colCovered := make([]bool, cols)
+	for a, b := range assigned {
+		if b >= 0 && cost[a][b] < 0 {
+			edges = append(edges, [2]int{a, b})
+			rowCovered[a], colCovered[b] = true, true
+		}
+	}
+	for a, ok := range rowCovered {
+		if !ok {
+			edges = append(edges, [2]int{a, rowBest[a]})
+		}
+	}
+	for b, ok := range colCovered {
+		if !ok {
+			edges = append(edges, [2]int{colBest[b], b})
+		}
+	}
+	return edges
+}
+
+// starSets groups cover edges into matched sets. Each connected component
+// of a minimum edge cover is a star, so a set is one center with all of
+// its leaves.
+func starSets(edges [][2]int, treated, controls []int) []MatchedSet {
+	rowDegree := make(map[int]int)
+	colDegree := make(map[int]int)
+	for _, e := range edges {
+		rowDegree[e[0]]++
+		colDegree[e[1]]++
+	}
+
+	// With tied distances the cover can contain an edge whose ends are both
+	// covered elsewhere; dropping it keeps a cover at no extra cost
+	kept := edges[:0]
+	for _, e := range edges {
+		if rowDegree[e[0]] > 1 && colDegree[e[1]] > 1 {
+			rowDegree[e[0]]--
+			colDegree[e[1]]--
+			continue
+		}
+		kept = append(kept, e)
+	}
+	edges = kept
+
+	// A treated unit with several edges is a center; otherwise the control is
+	byTreated := make(map[int][]int)
+	byControl := make(map[int][]int)
+	for _, e := range edges {
+		if rowDegree[e[0]] > 1 || colDegree[e[1]] == 1 {
+			byTreated[e[0]] = append(byTreated[e[0]], controls[e[1]])
+		} else {
+			byControl[e[1]] = append(byControl[e[1]], treated[e[0]])
+		}
+	}
+
+	var sets []MatchedSet
+	for a, cs := range byTreated {
+		sort.Ints(cs)
+		sets = append(sets, MatchedSet{Treated: []int{treated[a]}, Controls: cs})
+	}
+	for b, ts := range byControl {
+		sort.Ints(ts)
+		sets = append(sets, MatchedSet{Treated: ts, Controls: []int{controls[b]}})
+	}
+	// Map iteration order is random; report sets by their first treated unit
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Treated[0] != sets[j].Treated[0] {
+			return sets[i].Treated[0] < sets[j].Treated[0]
+		}
+		return sets[i].Controls[0] < sets[j].Controls[0]
+	})
+	return sets
+}
+
+// hungarian solves the rectangular assignment problem for the cost matrix,
+// returning the column assigned to each row, or -1 when there are more
+// rows than columns and the row is left out. It uses the shortest
+// augmenting path form of the Hungarian algorithm with potentials.
+func hungarian(cost [][]float64) []int {
+	n, m := len(cost), len(cost[0])
+	if n > m {
+		// Solve the transpose so that rows never outnumber columns
+		byCol := hungarian(transpose(cost))
+		out := make([]int, n)
+		for i := range out {
+			out[i] = -1
+		}
+		for j, i := range byCol {
+			out[i] = j
+		}
+		return out
+	}
+
+	// 1-based arrays; p[j] is the row matched to column j and column 0 is
+	// a sentinel used while growing each augmenting path
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+	minv := make([]float64, m+1)
+	used := make([]bool, m+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		for j := range minv {
+			minv[j] = math.Inf(1)
+			used[j] = false
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		// Flip the augmenting path back to its root
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	out := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			out[p[j]-1] = j - 1
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHungarianMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cost := make([][]float64, 5)
+	for i := range cost {
+		cost[i] = make([]float64, 5)
+		for j := range cost[i] {
+			cost[i][j] = rng.Float64()
+		}
+	}
+
+	// Enumerate all permutations for the optimum
+	best := math.Inf(1)
+	perm := []int{0, 1, 2, 3, 4}
+	var permute func(k int)
+	permute = func(k int) {
+		if k == len(perm) {
+			var c float64
+			for i, j := range perm {
+				c += cost[i][j]
+			}
+			best = math.Min(best, c)
+			return
+		}
+		for i := k; i < len(perm); i++ {
+			perm[k], perm[i] = perm[i], perm[k]
+			permute(k + 1)
+			perm[k], perm[i] = perm[i], perm[k]
+		}
+	}
+	permute(0)
+
+	var got float64
+	for i, j := range hungarian(cost) {
+		got += cost[i][j]
+	}
+	if math.Abs(got-best) > 1e-12 {
+		t.Errorf("Hungarian cost %.6f, optimum %.6f", got, best)
+	}
+
+	// With more rows than columns the extra rows are left out
+	narrow := make([][]float64, len(cost))
+	for i := range cost {
+		narrow[i] = cost[i][:3]
+	}
+	seen := make(map[int]bool)
+	var left int
+	for _, j := range hungarian(narrow) {
+		if j < 0 {
+			left++
+		} else if seen[j] {
+			t.Fatalf("column %d assigned twice", j)
+		} else {
+			seen[j] = true
+		}
+	}
+	if left != 2 {
+		t.Errorf("%d rows unassigned, want 2", left)
+	}
+}
+
+func TestMinimumEdgeCover(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	d := make([][]float64, 3)
+	for a := range d {
+		d[a] = make([]float64, 4)
+		for b := range d[a] {
+			d[a][b] = rng.Float64()
+		}
+	}
+
+	// Brute force over all 2^12 edge subsets that cover every vertex
+	best := math.Inf(1)
+	for mask := 0; mask < 1<<12; mask++ {
+		var rows [3]bool
+		var cols [4]bool
+		var c float64
+		for e := 0; e < 12; e++ {
+			if mask&(1<<e) != 0 {
+				rows[e/4], cols[e%4] = true, true
+				c += d[e/4][e%4]
+			}
+		}
+		if rows[0] && rows[1] && rows[2] && cols[0] && cols[1] && cols[2] && cols[3] {
+			best = math.Min(best, c)
+		}
+	}
+
+	var got float64
+	for _, e := range minimumEdgeCover(d) {
+		got += d[e[0]][e[1]]
+	}
+	if math.Abs(got-best) > 1e-12 {
+		t.Errorf("cover cost %.6f, optimum %.6f", got, best)
+	}
+}
+
+func TestFullMatching(t *testing.T) {
+	data := GenerateCausalData(600, 123)
+	res, err := MatchFull(data, FullMatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every unit lands in exactly one set, and each set is a star
+	seen := make(map[int]int)
+	for _, s := range res.Sets {
+		if len(s.Treated) != 1 && len(s.Controls) != 1 {
+			t.Fatalf("set %+v has several units of both arms", s)
+		}
+		for _, i := range append(append([]int(nil), s.Treated...), s.Controls...) {
+			seen[i]++
+		}
+	}
+	for i := range data.X {
+		if seen[i] != 1 {
+			t.Fatalf("unit %d appears in %d sets", i, seen[i])
+		}
+	}
+
+	naive := naiveBias(data)
+	if bias := math.Abs(res.Estimate - data.TrueEffect); bias >= naive {
+		t.Errorf("full matching bias %.4f not below naive %.4f", bias, naive)
+	}
+}
@@ -0,0 +1,18 @@
+package causalinference
+
+import "testing"
+
+func TestGComputation(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	res, err := EstimateGComputation(data, GCompOptions{Bootstrap: 100, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v does not cover %.1f (estimate %.4f)", res.CI, data.TrueEffect, res.Estimate)
+	}
+	if res.SE <= 0 {
+		t.Errorf("bootstrap SE should be positive, got %f", res.SE)
+	}
+}
@@ -0,0 +1,259 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// GeneticMatchOptions configures genetic matching
+type GeneticMatchOptions struct {
+	Population  int   // candidate weight vectors per generation; 0 means 50
+	Generations int   // generations to evolve; 0 means 20
+	Seed        int64 // seed for the evolutionary search
+}
+
+// GeneticMatchResult holds the matched sample found by genetic matching
+type GeneticMatchResult struct {
+	MatchResult
+	// Weights holds the chosen weight of each matching variable: the
+	// covariates followed by the propensity score
+	Weights []float64
+	Loss    float64 // balance loss of the chosen matching; lower is better
+}
+
+// MatchGenetic performs genetic matching in the style of Matching's
+// GenMatch. Treated units are matched with replacement to their nearest
+// control under a weighted Mahalanobis distance on the covariates and the
+// propensity score, and an evolutionary search chooses the weights that
+// give the best balance. The loss of a matching is the worst imbalance over
+// the matching variables, taking for each the larger of its absolute
+// standardized mean difference and its Kolmogorov-Smirnov statistic.
+// Each generation keeps the best tenth of its candidates and fills the
+// rest by tournament selection, uniform crossover and Gaussian mutation of
+// the log10 weights, which range over [-1, 3]. Candidates are evaluated in
+// parallel. The effect is the ATT from the best matching found.
+func MatchGenetic(data *CausalData, opts GeneticMatchOptions) (GeneticMatchResult, error) {
+	pop := opts.Population
+	if pop < 2 {
+		pop = 50
+	}
+	gens := opts.Generations
+	if gens < 1 {
+		gens = 20
+	}
+
+	if err := requireBothArms(data); err != nil {
+		return GeneticMatchResult{}, err
+	}
+	scores, err := propensityScores(data)
+	if err != nil {
+		return GeneticMatchResult{}, err
+	}
+	vars := covariateRows(data)
+	for i := range vars {
+		vars[i] = append(vars[i], scores[i])
+	}
+	base, err := mahalanobisPoints(vars)
+	if err != nil {
+		return GeneticMatchResult{}, err
+	}
+
+	var treated, controls []int
+	for i, t := range data.Treatment {
+		if t == 1 {
+			treated = append(treated, i)
+		} else {
+			controls = append(controls, i)
+		}
+	}
+
+	p := len(vars[0])
+	// match returns the matched sets for the weights implied by genes
+	match := func(genes []float64) []MatchedSet {
+		points := make([][]float64, len(base))
+		for i, z := range base {
+			points[i] = make([]float64, p)
+			for j, v := range z {
+				points[i][j] = v * math.Sqrt(math.Pow(10, genes[j]))
+			}
+		}
+		sets, _ := matchFocal(treated, controls, scores, points, MatchOptions{Replace: true, Distance: DistanceMahalanobis}, true)
+		return sets
+	}
+	lossOf := func(genes []float64) float64 { return balanceLoss(vars, match(genes)) }
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	population := make([][]float64, pop)
+	for k := range population {
+		population[k] = make([]float64, p)
+		// Keep the unweighted Mahalanobis distance as the first candidate
+		if k > 0 {
+			for j := range population[k] {
+				population[k][j] = -1 + 4*rng.Float64()
+			}
+		}
+	}
+
+	losses := make([]float64, pop)
+	order := make([]int, pop)
+	for g := 0; g < gens; g++ {
+		evaluateParallel(population, losses, lossOf)
+		for k := range order {
+			order[k] = k
+		}
+		sort.SliceStable(order, func(a, b int) bool { return losses[order[a]] < losses[order[b]] })
+		if g == gens-1 {
+			break
+		}
+
+		elite := pop / 10
+		if elite < 1 {
+			elite = 1
+		}
+		next := make([][]float64, 0, pop)
+		for _, k := range order[:elite] {
+			next = append(next, population[k])
+		}
+		tournament := func() []float64 {
+			a, b := rng.Intn(pop), rng.Intn(pop)
+			if losses[b] < losses[a] {
+				a = b
+			}
+			return population[a]
+		}
+		for len(next) < pop {
+			mum, dad := tournament(), tournament()
+			child := make([]float64, p)
+			for j := range child {
+				child[j] = mum[j]
+				if rng.Float64() < 0.5 {
+					child[j] = dad[j]
+				}
+				if rng.Float64() < 0.2 {
+					child[j] += 0.3 * rng.NormFloat64()
+				}
+				child[j] = math.Max(-1, math.Min(3, child[j]))
+			}
+			next = append(next, child)
+		}
+		population = next
+	}
+
+	best := population[order[0]]
+	res, err := matchedEffect(data, match(best), EstimandATT)
+	if err != nil {
+		return GeneticMatchResult{}, err
+	}
+	res.Method = "GeneticMatching"
+
+	weights := make([]float64, p)
+	for j, g := range best {
+		weights[j] = math.Pow(10, g)
+	}
+	return GeneticMatchResult{MatchResult: res, Weights: weights, Loss: losses[order[0]]}, nil
+}
+
+// evaluateParallel fills losses with f of each candidate using one worker
+// per CPU
+func evaluateParallel(candidates [][]float64, losses []float64, f func([]float64) float64) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				losses[k] = f(candidates[k])
+			}
+		}()
+	}
+	for k := range candidates {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// balanceLoss returns the worst imbalance over the columns of vars between
+// the treated units and their weighted matched controls: for each column
+// the larger of the absolute standardized mean difference, scaled by the
+// treated standard deviation, and the Kolmogorov-Smirnov statistic
+func balanceLoss(vars [][]float64, sets []MatchedSet) float64 {
+	var treated, controls []int
+	var treatedW, controlW []float64
+	for _, s := range sets {
+		for _, i := range s.Treated {
+			treated = append(treated, i)
+			treatedW = append(treatedW, 1)
+			for _, j := range s.Controls {
+				controls = append(controls, j)
+				controlW = append(controlW, 1/float64(len(s.Controls)))
+			}
+		}
+	}
+
+	var loss float64
+	for j := range vars[0] {
+		t := make([]float64, len(treated))
+		for k, i := range treated {
+			t[k] = vars[i][j]
+		}
+		c := make([]float64, len(controls))
+		for k, i := range controls {
+			c[k] = vars[i][j]
+		}
+
+		var mt, mc, wc float64
+		for _, v := range t {
+			mt += v / float64(len(t))
+		}
+		for k, v := range c {
+			mc += controlW[k] * v
+			wc += controlW[k]
+		}
+		mc /= wc
+		sd := stdDev(t)
+		if sd > 0 {
+			loss = math.Max(loss, math.Abs(mt-mc)/sd)
+		}
+		loss = math.Max(loss, weightedKS(t, treatedW, c, controlW))
+	}
+	return loss
+}
+
+// weightedKS returns the largest gap between the weighted empirical CDFs
+// of two samples
+func weightedKS(a, wa, b, wb []float64) float64 {
+	type point struct {
+		v, w  float64
+		first bool
+	}
+	var totalA, totalB float64
+	pts := make([]point, 0, len(a)+len(b))
+	for k, v := range a {
+		pts = append(pts, point{v, wa[k], true})
+		totalA += wa[k]
+	}
+	for k, v := range b {
+		pts = append(pts, point{v, wb[k], false})
+		totalB += wb[k]
+	}
+	sort.Slice(pts, func(i, j int) bool { return pts[i].v < pts[j].v })
+
+	var fa, fb, gap float64
+	for k, pt := range pts {
+		if pt.first {
+			fa += pt.w / totalA
+		} else {
+			fb += pt.w / totalB
+		}
+		// Only compare after all points tied at this value are counted
+		if k+1 == len(pts) || pts[k+1].v != pt.v {
+			gap = math.Max(gap, math.Abs(fa-fb))
+		}
+	}
+	return gap
+}
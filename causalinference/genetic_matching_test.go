@@ -0,0 +1,54 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeneticMatching(t *testing.T) {
+	data := GenerateCausalData(1000, 123)
+
+	res, err := MatchGenetic(data, GeneticMatchOptions{Population: 20, Generations: 5, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Weights) != 2 || res.Method != "GeneticMatching" {
+		t.Fatalf("unexpected result %+v", res.EffectResult)
+	}
+
+	// Evolved weights should balance at least as well as plain Mahalanobis matching
+	plain, err := MatchNearestNeighbor(data, MatchOptions{Replace: true, Distance: DistanceMahalanobis, Ratio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := covariateRows(data)
+	scores := EstimatePropensityScores(data)
+	for i := range vars {
+		vars[i] = append(vars[i], scores[i])
+	}
+	if res.Loss > balanceLoss(vars, plain.Sets)+1e-12 {
+		t.Errorf("genetic loss %.4f worse than plain Mahalanobis %.4f", res.Loss, balanceLoss(vars, plain.Sets))
+	}
+
+	naive := naiveBias(data)
+	if bias := math.Abs(res.Estimate - data.TrueEffect); bias >= naive {
+		t.Errorf("genetic matching bias %.4f not below naive %.4f", bias, naive)
+	}
+
+	// Results do not depend on how candidates are scheduled across workers
+	again, _ := MatchGenetic(data, GeneticMatchOptions{Population: 20, Generations: 5, Seed: 1})
+	if again.Estimate != res.Estimate {
+		t.Error("genetic matching is not reproducible for a fixed seed")
+	}
+}
+
+func TestWeightedKS(t *testing.T) {
+	a := []float64{1, 2, 3}
+	b := []float64{4, 5}
+	if d := weightedKS(a, []float64{1, 1, 1}, b, []float64{1, 1}); d != 1 {
+		t.Errorf("disjoint samples: KS %.3f, want 1", d)
+	}
+	if d := weightedKS(a, []float64{1, 1, 1}, a, []float64{2, 2, 2}); d > 1e-12 {
+		t.Errorf("identical samples: KS %.3f, want 0", d)
+	}
+}
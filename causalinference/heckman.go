@@ -0,0 +1,195 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+)
+
+// SelectionData holds an outcome that is observed only for a selected
+// subsample
+type SelectionData struct {
+	X         []float64 // covariate in both the selection and outcome equations
+	Z         []float64 // excluded variable that shifts selection only
+	Selected  []int     // 1 if the outcome is observed
+	Outcome   []float64 // observed outcome; NaN when not selected
+	TrueSlope float64   // coefficient on X in the outcome equation, for testing
+}
+
+// GenerateSelectionData creates data with outcome Y = 1 + 2X + e, observed
+// only when 0.5 + X + Z + u > 0, where (u, e) are standard bivariate normal
+// with correlation 0.6. Selection on u makes e correlated with X among the
+// selected units, so OLS on the observed sample is biased.
+func GenerateSelectionData(n int, seed int64) *SelectionData {
+	rng := rand.New(rand.NewSource(seed))
+
+	const rho = 0.6
+	data := &SelectionData{
+		X:         make([]float64, n),
+		Z:         make([]float64, n),
+		Selected:  make([]int, n),
+		Outcome:   make([]float64, n),
+		TrueSlope: 2.0,
+	}
+
+	for i := 0; i < n; i++ {
+		x, z := rng.NormFloat64(), rng.NormFloat64()
+		u := rng.NormFloat64()
+		e := rho*u + math.Sqrt(1-rho*rho)*rng.NormFloat64()
+
+		data.X[i], data.Z[i] = x, z
+		data.Outcome[i] = math.NaN()
+		if 0.5+x+z+u > 0 {
+			data.Selected[i] = 1
+			data.Outcome[i] = 1 + data.TrueSlope*x + e
+		}
+	}
+
+	return data
+}
+
+// HeckmanResult holds the selection-corrected outcome equation
+type HeckmanResult struct {
+	EffectResult           // coefficient on X
+	Coef         []float64 // outcome equation: intercept, X, inverse Mills ratio
+	Rho          float64   // estimated correlation of the selection and outcome errors
+	Sigma        float64   // estimated outcome error standard deviation
+}
+
+// EstimateHeckman fits the two-step selection model of Heckman (1979). A
+// probit of selection on X and Z gives each selected unit's inverse Mills
+// ratio, which is added as a regressor in the OLS fit of the observed
+// outcomes on X. The standard error uses Heckman's corrected covariance,
+// which accounts for the heteroskedasticity induced by selection and for
+// the estimated probit coefficients.
+func EstimateHeckman(data *SelectionData) (HeckmanResult, error) {
+	n := len(data.X)
+	w := make([][]float64, n)
+	s := make([]float64, n)
+	for i := range w {
+		w[i] = []float64{1, data.X[i], data.Z[i]}
+		s[i] = float64(data.Selected[i])
+	}
+	gamma, probitCov, err := fitProbit(w, s)
+	if err != nil {
+		return HeckmanResult{}, err
+	}
+
+	// Outcome equation on the selected units with the Mills ratio added
+	var x [][]float64
+	var y, delta []float64
+	var rows []int
+	for i := range w {
+		if data.Selected[i] == 0 {
+			continue
+		}
+		eta := dot(w[i], gamma)
+		lambda := normalDensity(eta, 0, 1) / normalCDF(eta)
+		x = append(x, []float64{1, data.X[i], lambda})
+		y = append(y, data.Outcome[i])
+		delta = append(delta, lambda*(lambda+eta))
+		rows = append(rows, i)
+	}
+	if len(y) <= 3 {
+		return HeckmanResult{}, ErrNoObservations
+	}
+	fit, err := regress(x, y)
+	if err != nil {
+		return HeckmanResult{}, err
+	}
+
+	// sigma^2 = e'e/m + betaLambda^2 * mean(delta), rho = betaLambda/sigma
+	m := float64(len(y))
+	var rss, meanDelta float64
+	for i, r := range fit.resid {
+		rss += r * r
+		meanDelta += delta[i] / m
+	}
+	bl := fit.coef[2]
+	sigma2 := rss/m + bl*bl*meanDelta
+	rho := bl / math.Sqrt(sigma2)
+
+	// V = sigma^2 (X'X)^-1 [X'(I - rho^2 D)X + rho^2 (X'DW) Vp (W'DX)] (X'X)^-1
+	p, q := len(x[0]), len(gamma)
+	inner := make([][]float64, p)
+	xdw := make([][]float64, p)
+	for a := range inner {
+		inner[a] = make([]float64, p)
+		xdw[a] = make([]float64, q)
+	}
+	for k, row := range x {
+		for a := 0; a < p; a++ {
+			for b := 0; b < p; b++ {
+				inner[a][b] += (1 - rho*rho*delta[k]) * row[a] * row[b]
+			}
+			for b := 0; b < q; b++ {
+				xdw[a][b] += delta[k] * row[a] * w[rows[k]][b]
+			}
+		}
+	}
+	correction := matMul(matMul(xdw, probitCov), transpose(xdw))
+	for a := range inner {
+		for b := range inner[a] {
+			inner[a][b] += rho * rho * correction[a][b]
+		}
+	}
+	cov := matMul(matMul(fit.xtxInv, inner), fit.xtxInv)
+	se := math.Sqrt(sigma2 * cov[1][1])
+
+	return HeckmanResult{
+		EffectResult: newEffectResult("Heckman", fit.coef[1], se, len(y)),
+		Coef:         fit.coef,
+		Rho:          rho,
+		Sigma:        math.Sqrt(sigma2),
+	}, nil
+}
+
+// fitProbit fits a probit regression of y on the rows of x by Newton's
+// method and returns the coefficients with their inverse-information
+// covariance. Rows of x should already contain an intercept column.
+func fitProbit(x [][]float64, y []float64) ([]float64, [][]float64, error) {
+	p := len(x[0])
+	beta := make([]float64, p)
+
+	var info [][]float64
+	for iter := 0; iter < irlsMaxIter; iter++ {
+		info = make([][]float64, p)
+		for j := range info {
+			info[j] = make([]float64, p)
+		}
+		grad := make([]float64, p)
+
+		for i, row := range x {
+			q := 2*y[i] - 1
+			eta := dot(row, beta)
+			// Guard the ratio against underflow for badly predicted points
+			cdf := math.Max(normalCDF(q*eta), 1e-300)
+			lambda := q * normalDensity(q*eta, 0, 1) / cdf
+			w := lambda * (lambda + eta)
+			for j := 0; j < p; j++ {
+				grad[j] += lambda * row[j]
+				for k := 0; k < p; k++ {
+					info[j][k] += w * row[j] * row[k]
+				}
+			}
+		}
+
+		step, err := solveLinear(info, grad)
+		if err != nil {
+			return nil, nil, err
+		}
+		var change float64
+		for j := range beta {
+			beta[j] += step[j]
+			change = math.Max(change, math.Abs(step[j]))
+		}
+		if change < irlsTolerance {
+			break
+		}
+	}
+
+	cov, err := invertMatrix(info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beta, cov, nil
+}
@@ -0,0 +1,56 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHeckmanCorrectsSelection(t *testing.T) {
+	data := GenerateSelectionData(5000, 123)
+
+	res, err := EstimateHeckman(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueSlope) > 4*res.SE {
+		t.Errorf("slope %.3f (SE %.3f), want %.1f", res.Estimate, res.SE, data.TrueSlope)
+	}
+	if math.Abs(res.Rho-0.6) > 0.2 {
+		t.Errorf("rho %.3f, want about 0.6", res.Rho)
+	}
+
+	// OLS on the selected sample ignores the selection
+	var x [][]float64
+	var y []float64
+	for i, s := range data.Selected {
+		if s == 1 {
+			x = append(x, []float64{1, data.X[i]})
+			y = append(y, data.Outcome[i])
+		}
+	}
+	naive, _ := fitOLS(x, y)
+	if math.Abs(res.Estimate-data.TrueSlope) >= math.Abs(naive[1]-data.TrueSlope) {
+		t.Errorf("Heckman slope %.3f no better than OLS %.3f", res.Estimate, naive[1])
+	}
+}
+
+func TestFitProbit(t *testing.T) {
+	// Probit coefficients recovered from a known latent index
+	data := GenerateSelectionData(20000, 7)
+	w := make([][]float64, len(data.X))
+	s := make([]float64, len(data.X))
+	for i := range w {
+		w[i] = []float64{1, data.X[i], data.Z[i]}
+		s[i] = float64(data.Selected[i])
+	}
+	beta, _, err := fitProbit(w, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0.5, 1, 1}
+	for j := range want {
+		if math.Abs(beta[j]-want[j]) > 0.05 {
+			t.Errorf("probit coef %d: %.3f, want %.1f", j, beta[j], want[j])
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package causalinference
+
+import "math"
+
+// HeterogeneityTest is a calibration test of whether predicted treatment
+// effects capture real variation in the effect
+type HeterogeneityTest struct {
+	Mean float64 // coefficient on treatment, the average effect
+	// Differential is the coefficient on the centred predictions: near 1
+	// when they are well calibrated, near 0 when the effect is constant
+	Differential float64
+	SE           float64 // HC2 standard error of Differential
+	Statistic    float64 // Differential / SE
+	PValue       float64 // one-sided p-value for no heterogeneity against Differential > 0
+}
+
+// CalibrationTest checks whether the per-unit effect predictions cate
+// explain heterogeneity in the outcome, in the spirit of
+// grf::test_calibration and the best linear predictor of Chernozhukov et
+// al. (2018): the outcome is regressed on an intercept, the covariates,
+// treatment and treatment times cate minus its mean, and the last
+// coefficient is tested against zero. Units with a NaN prediction are
+// skipped. When the predictions are evaluated on the data used to fit
+// them, flexible learners overfit and the p-value is optimistic. If the
+// predictions do not vary the test is undefined and PValue is 1; if fewer
+// units have a prediction than the regression has coefficients it returns
+// ErrNoObservations.
+func CalibrationTest(data *CausalData, cate []float64) (HeterogeneityTest, error) {
+	if len(cate) != len(data.Outcome) {
+		return HeterogeneityTest{}, ErrLengthMismatch
+	}
+
+	var mean float64
+	var used int
+	for _, c := range cate {
+		if !math.IsNaN(c) {
+			mean += c
+			used++
+		}
+	}
+
+	rows := covariateRows(data)
+	if len(rows) == 0 || used < len(rows[0])+3 {
+		return HeterogeneityTest{}, ErrNoObservations
+	}
+	mean /= float64(used)
+
+	var x [][]float64
+	var y []float64
+	var spread float64
+	for i, c := range cate {
+		if math.IsNaN(c) {
+			continue
+		}
+		t := float64(data.Treatment[i])
+		row := append([]float64{1}, rows[i]...)
+		x = append(x, append(row, t, t*(c-mean)))
+		y = append(y, data.Outcome[i])
+		spread = math.Max(spread, math.Abs(c-mean))
+	}
+	if spread < 1e-9*math.Max(1, math.Abs(mean)) {
+		return HeterogeneityTest{Mean: mean, PValue: 1}, nil
+	}
+
+	fit, err := regress(x, y)
+	if err != nil {
+		return HeterogeneityTest{}, err
+	}
+	cov := fit.covariance(x, nil, VarianceHC2)
+	j := len(fit.coef) - 1
+	res := HeterogeneityTest{
+		Mean:         fit.coef[j-1],
+		Differential: fit.coef[j],
+		SE:           math.Sqrt(cov[j][j]),
+	}
+	res.Statistic = res.Differential / res.SE
+	res.PValue = 1 - normalCDF(res.Statistic)
+	return res, nil
+}
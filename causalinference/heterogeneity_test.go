@@ -0,0 +1,53 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalibrationTest(t *testing.T) {
+	// The effect is x, so T-learner predictions are calibrated
+	data := heterogeneousData(2000, 5)
+	res, err := TLearner{}.EstimateCATE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := res.Heterogeneity
+	if math.Abs(h.Differential-1) > 0.15 || h.PValue > 1e-6 || math.Abs(h.Mean-res.ATE) > 0.1 {
+		t.Errorf("heterogeneity not detected: %+v", h)
+	}
+
+	// A constant effect leaves only noise in the T-learner predictions
+	flat := GenerateCausalData(2000, 5)
+	res, err = TLearner{}.EstimateCATE(flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Heterogeneity.PValue < 0.01 {
+		t.Errorf("spurious heterogeneity: %+v", res.Heterogeneity)
+	}
+
+	// Constant predictions cannot be tested
+	constant := make([]float64, len(flat.Outcome))
+	if h, err := CalibrationTest(flat, constant); err != nil || h.PValue != 1 {
+		t.Errorf("constant predictions: %+v, %v", h, err)
+	}
+	if _, err := CalibrationTest(flat, constant[1:]); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch, got %v", err)
+	}
+}
+
+func TestCalibrationTestNoPredictions(t *testing.T) {
+	data := GenerateCausalData(50, 1)
+	cate := make([]float64, 50)
+	for i := range cate {
+		cate[i] = math.NaN()
+	}
+	if _, err := CalibrationTest(data, cate); err != ErrNoObservations {
+		t.Errorf("all-NaN predictions: error %v, want ErrNoObservations", err)
+	}
+	cate[0], cate[1] = 1, 2
+	if _, err := CalibrationTest(data, cate); err != ErrNoObservations {
+		t.Errorf("two predictions: error %v, want ErrNoObservations", err)
+	}
+}
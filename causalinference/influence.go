@@ -0,0 +1,32 @@
+package causalinference
+
+import "math"
+
+// influenceSE returns the standard error of an asymptotically linear
+// estimator from its estimated influence function values ic,
+// sqrt(var(ic)/n) as R's tmle reports. With cluster identifiers the values
+// are first summed within clusters, as with the id argument of tmle, and
+// the variance gets the G/(G-1) small-sample factor.
+func influenceSE(ic []float64, cluster []int) float64 {
+	if cluster == nil {
+		_, se := meanAndSE(ic)
+		return se
+	}
+
+	n := float64(len(ic))
+	var mean float64
+	for _, v := range ic {
+		mean += v / n
+	}
+	groups := clusterRows(len(ic), cluster)
+	var ss float64
+	for _, rows := range groups {
+		var sum float64
+		for _, i := range rows {
+			sum += ic[i] - mean
+		}
+		ss += sum * sum
+	}
+	g := float64(len(groups))
+	return math.Sqrt(g/(g-1)*ss) / n
+}
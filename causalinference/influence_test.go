@@ -0,0 +1,48 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInfluenceSE(t *testing.T) {
+	// Singleton clusters reproduce the unclustered value exactly
+	ic := []float64{1, -2, 0.5, 3, -1.5}
+	_, plain := meanAndSE(ic)
+	if got := influenceSE(ic, []int{0, 1, 2, 3, 4}); math.Abs(got-plain) > 1e-12 {
+		t.Errorf("singleton clusters SE %.6f, want %.6f", got, plain)
+	}
+
+	// Treatment assigned by cluster, with a shared cluster shock
+	data := GenerateCausalData(2000, 4)
+	rng := rand.New(rand.NewSource(4))
+	shocks := make([]float64, 40)
+	for g := range shocks {
+		shocks[g] = 2 * rng.NormFloat64()
+	}
+	data.Cluster = make([]int, len(data.X))
+	for i := range data.X {
+		g := rng.Intn(20)*2 + data.Treatment[i]
+		data.Cluster[i] = g
+		data.Outcome[i] += shocks[g]
+	}
+
+	estimators := map[string]Estimator{
+		"AIPW": EstimateAIPW,
+		"TMLE": EstimateTMLE,
+		"DML":  func(d *CausalData) (EffectResult, error) { return EstimateDML(d, DMLOptions{Seed: 1}) },
+	}
+	for name, estimate := range estimators {
+		clustered, err := estimate(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unclustered := subsetData(data, allUnits(len(data.X)))
+		unclustered.Cluster = nil
+		plain, _ := estimate(unclustered)
+		if clustered.Estimate != plain.Estimate || clustered.SE < 2*plain.SE {
+			t.Errorf("%s: clustered SE %.4f, unclustered %.4f", name, clustered.SE, plain.SE)
+		}
+	}
+}
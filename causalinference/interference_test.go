@@ -0,0 +1,41 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInterferenceEffects(t *testing.T) {
+	data := GenerateInterferenceData(400, 20, 123)
+
+	// Links never cross groups
+	for i, nb := range data.Neighbors {
+		if len(nb) == 0 {
+			t.Fatalf("unit %d has no neighbors", i)
+		}
+		for _, j := range nb {
+			if data.Group[j] != data.Group[i] {
+				t.Fatalf("unit %d linked across groups", i)
+			}
+		}
+	}
+
+	res, err := EstimateInterference(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Direct) != 2 || res.Direct[0].Saturation != 0.3 {
+		t.Fatalf("unexpected saturation levels %+v", res.Direct)
+	}
+	for _, d := range res.Direct {
+		if math.Abs(d.Estimate-data.DirectEffect) > 4*d.SE {
+			t.Errorf("direct effect at %.1f: %.3f (SE %.3f), want %.1f", d.Saturation, d.Estimate, d.SE, data.DirectEffect)
+		}
+	}
+
+	// Moving from 30% to 70% saturation raises the treated neighbor share by 0.4
+	want := data.SpilloverEffect * 0.4
+	if math.Abs(res.Spillover.Estimate-want) > 4*res.Spillover.SE {
+		t.Errorf("spillover %.3f (SE %.3f), want %.2f", res.Spillover.Estimate, res.Spillover.SE, want)
+	}
+}
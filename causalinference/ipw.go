@@ -0,0 +1,330 @@
+package causalinference
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrUnsupportedEstimand is returned when a method cannot target the
+// requested estimand
+var ErrUnsupportedEstimand = errors.New("causalinference: estimand not supported by this method")
+
+// WeightScheme selects how balancing weights are constructed
+type WeightScheme int
+
+const (
+	// WeightIPW uses inverse probability weights from a logistic propensity model
+	WeightIPW WeightScheme = iota
+	// WeightEntropy uses entropy balancing weights; it targets the ATT only
+	WeightEntropy
+	// WeightOverlap uses overlap weights, which target the average effect
+	// in the overlap population (ATO)
+	WeightOverlap
+)
+
+// PropensityModel selects how propensity scores are estimated for weighting
+type PropensityModel int
+
+const (
+	// PropensityLogit fits a logistic regression by maximum likelihood
+	PropensityLogit PropensityModel = iota
+	// PropensityCBPS fits the exactly balancing covariate balancing
+	// propensity score
+	PropensityCBPS
+)
+
+// WeightingOptions configures the weighting pipeline
+type WeightingOptions struct {
+	Scheme     WeightScheme    // how weights are built; defaults to IPW
+	Estimand   Estimand        // target population; defaults to ATE (ATT for entropy balancing, ATO for overlap)
+	Propensity PropensityModel // propensity model for IPW; defaults to logistic MLE
+	// Trim drops units whose propensity score lies outside [Trim, 1-Trim];
+	// 0 disables. It has no effect on entropy balancing.
+	Trim float64
+	// Truncate caps weights at this quantile of the weight distribution,
+	// e.g. 0.99; 0 disables
+	Truncate float64
+	// Stabilize multiplies IPW weights by the marginal probability of each
+	// unit's observed arm, so ATE weights average about one
+	Stabilize bool
+	Scale     EffectScale // scale of the reported effect; ratio scales need a 0/1 outcome
+}
+
+// WeightingResult holds a weighted effect estimate and the weights behind it
+type WeightingResult struct {
+	EffectResult
+	Weights   []float64        // final weight of each unit; zero for trimmed units
+	Trimmed   int              // units dropped by trimming
+	Truncated int              // weights capped by truncation
+	Summary   [2]WeightSummary // weight distribution by arm, indexed by treatment
+}
+
+// WeightSummary describes the distribution of the positive weights in one
+// arm, with diagnostics for how much a few large weights dominate it
+type WeightSummary struct {
+	Min, Q1, Median, Q3, Max float64
+	Mean, SD                 float64
+	// ESS is Kish's effective sample size (sum w)^2 / sum w^2, the number
+	// of equally weighted units carrying the same information
+	ESS      float64
+	MaxRatio float64 // largest weight over the mean weight
+}
+
+// EstimateIPW estimates the average treatment effect by weighting each
+// outcome by the inverse of its estimated probability of the observed
+// treatment. Weights are normalised within each arm (the Hajek estimator),
+// which keeps the estimate stable when a few scores are close to 0 or 1.
+// It is EstimateWeighted with default options.
+func EstimateIPW(data *CausalData) (EffectResult, error) {
+	res, err := EstimateWeighted(data, WeightingOptions{})
+	if err != nil {
+		return EffectResult{}, err
+	}
+	return res.EffectResult, nil
+}
+
+// EstimateWeighted estimates a treatment effect by propensity score
+// weighting, with weights chosen for the requested estimand:
+//
+//	ATE: T/e + (1-T)/(1-e)
+//	ATT: T + (1-T)e/(1-e)
+//	ATC: T(1-e)/e + (1-T)
+//
+// With WeightEntropy, control weights instead come from entropy balancing
+// and only the ATT is available. With WeightOverlap, units are weighted by
+// T(1-e) + (1-T)e and the target is fixed to the overlap population.
+// Trimming drops units with extreme scores, stabilization rescales IPW
+// weights by the arm shares and truncation then caps the largest weights.
+// Stabilizing leaves the normalised estimate unchanged but puts the
+// reported weights on a scale suitable for weighted regression. The
+// standard error is the sandwich variance of the two weighted means,
+// treating the weights as known, except for untrimmed and untruncated
+// overlap weights from the logistic model, whose sandwich also accounts
+// for estimating the propensity score. On the risk ratio and odds ratio
+// scales the standard error comes from the delta method and the interval
+// from the log scale.
+func EstimateWeighted(data *CausalData, opts WeightingOptions) (WeightingResult, error) {
+	if err := requireBothArms(data); err != nil {
+		return WeightingResult{}, err
+	}
+
+	var weights, scores []float64
+	var method string
+	var trimmed int
+	switch opts.Scheme {
+	case WeightEntropy:
+		if e := opts.Estimand.or(EstimandATT); e != EstimandATT {
+			return WeightingResult{}, ErrUnsupportedEstimand
+		}
+		var err error
+		weights, err = EntropyBalanceWeights(data)
+		if err != nil {
+			return WeightingResult{}, err
+		}
+		method = "EntropyBalancing"
+	default:
+		if opts.Scheme == WeightOverlap && opts.Estimand != EstimandDefault {
+			return WeightingResult{}, ErrUnsupportedEstimand
+		}
+		var err error
+		scores, err = weightingScores(data, opts.Propensity)
+		if err != nil {
+			return WeightingResult{}, err
+		}
+
+		estimand := opts.Estimand.or(EstimandATE)
+		weights = make([]float64, len(scores))
+		for i, p := range scores {
+			// Trimmed units keep a zero weight so indices still line up
+			if opts.Trim > 0 && (p < opts.Trim || p > 1-opts.Trim) {
+				trimmed++
+				continue
+			}
+			if opts.Scheme == WeightOverlap {
+				weights[i] = overlapWeight(data.Treatment[i], p)
+			} else {
+				weights[i] = balancingWeight(estimand, data.Treatment[i], p)
+			}
+		}
+		method = "IPW"
+		if opts.Scheme == WeightOverlap {
+			method = "OverlapWeights"
+		} else if opts.Stabilize {
+			stabilizeWeights(data.Treatment, weights)
+		}
+	}
+
+	truncated := truncateWeights(weights, opts.Truncate)
+
+	var total [2]float64
+	for i, w := range weights {
+		total[data.Treatment[i]] += w
+	}
+	if total[0] == 0 || total[1] == 0 {
+		return WeightingResult{}, ErrEmptyArm
+	}
+
+	if opts.Scale != ScaleDifference {
+		if err := requireBinaryOutcome(data.Outcome); err != nil {
+			return WeightingResult{}, err
+		}
+	}
+
+	res := weightedContrast(data, weights, method, opts.Scale)
+	if opts.Scheme == WeightOverlap && opts.Propensity == PropensityLogit && trimmed == 0 && truncated == 0 && opts.Scale == ScaleDifference {
+		se, err := overlapSandwichSE(data, scores)
+		if err != nil {
+			return WeightingResult{}, err
+		}
+		res.EffectResult = newEffectResult(res.Method, res.Estimate, se, res.N)
+	}
+	res.Trimmed, res.Truncated = trimmed, truncated
+	for g := range res.Summary {
+		res.Summary[g] = summarizeWeights(data.Treatment, weights, g)
+	}
+	return res, nil
+}
+
+// stabilizeWeights multiplies each weight by the sample share of the
+// unit's arm
+func stabilizeWeights(treatment []int, weights []float64) {
+	var treated float64
+	for _, t := range treatment {
+		treated += float64(t)
+	}
+	share := treated / float64(len(treatment))
+	for i, t := range treatment {
+		if t == 1 {
+			weights[i] *= share
+		} else {
+			weights[i] *= 1 - share
+		}
+	}
+}
+
+// summarizeWeights returns the distribution of the positive weights in arm g
+func summarizeWeights(treatment []int, weights []float64, g int) WeightSummary {
+	var w []float64
+	for i, v := range weights {
+		if treatment[i] == g && v > 0 {
+			w = append(w, v)
+		}
+	}
+	if len(w) == 0 {
+		return WeightSummary{}
+	}
+	sort.Float64s(w)
+	mean, _ := meanAndSE(w)
+	return WeightSummary{
+		Min:      w[0],
+		Q1:       sortedQuantile(w, 0.25),
+		Median:   sortedQuantile(w, 0.5),
+		Q3:       sortedQuantile(w, 0.75),
+		Max:      w[len(w)-1],
+		Mean:     mean,
+		SD:       stdDev(w),
+		ESS:      effectiveSampleSize(w),
+		MaxRatio: w[len(w)-1] / mean,
+	}
+}
+
+// effectiveSampleSize returns Kish's (sum w)^2 / sum w^2
+func effectiveSampleSize(w []float64) float64 {
+	var sum, squares float64
+	for _, v := range w {
+		sum += v
+		squares += v * v
+	}
+	return sum * sum / squares
+}
+
+// truncateWeights caps the positive weights at their q-th quantile in place
+// and returns how many were changed. A q outside (0, 1) leaves them alone.
+func truncateWeights(weights []float64, q float64) int {
+	if q <= 0 || q >= 1 {
+		return 0
+	}
+	var positive []float64
+	for _, w := range weights {
+		if w > 0 {
+			positive = append(positive, w)
+		}
+	}
+	if len(positive) == 0 {
+		return 0
+	}
+
+	limit := quantile(positive, q)
+	var count int
+	for i, w := range weights {
+		if w > limit {
+			weights[i] = limit
+			count++
+		}
+	}
+	return count
+}
+
+// weightingScores estimates propensity scores with the chosen model
+func weightingScores(data *CausalData, model PropensityModel) ([]float64, error) {
+	if model == PropensityCBPS {
+		return CBPSScores(data, CBPSOptions{})
+	}
+	return propensityScores(data)
+}
+
+// balancingWeight returns a unit's weight for the estimand given its arm
+// and propensity score
+func balancingWeight(estimand Estimand, t int, p float64) float64 {
+	switch estimand {
+	case EstimandATT:
+		if t == 1 {
+			return 1
+		}
+		return p / (1 - p)
+	case EstimandATC:
+		if t == 1 {
+			return (1 - p) / p
+		}
+		return 1
+	default:
+		if t == 1 {
+			return 1 / p
+		}
+		return 1 / (1 - p)
+	}
+}
+
+// weightedDifference computes the Hajek difference in weighted means and
+// its sandwich standard error
+func weightedDifference(data *CausalData, weights []float64, method string) WeightingResult {
+	return weightedContrast(data, weights, method, ScaleDifference)
+}
+
+// weightedContrast compares the Hajek weighted means of the two arms on the
+// given scale, with delta-method standard errors for the ratio scales
+func weightedContrast(data *CausalData, weights []float64, method string, scale EffectScale) WeightingResult {
+	var sum, total [2]float64
+	for i, w := range weights {
+		g := data.Treatment[i]
+		sum[g] += w * data.Outcome[i]
+		total[g] += w
+	}
+	mean := [2]float64{sum[0] / total[0], sum[1] / total[1]}
+
+	var variance [2]float64
+	var used int
+	for i, w := range weights {
+		if w > 0 {
+			used++
+		}
+		g := data.Treatment[i]
+		r := w * (data.Outcome[i] - mean[g]) / total[g]
+		variance[g] += r * r
+	}
+
+	return WeightingResult{
+		EffectResult: scaledEffect(scale, method, mean[1], mean[0], variance[1], variance[0], used),
+		Weights:      weights,
+	}
+}
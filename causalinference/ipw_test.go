@@ -0,0 +1,152 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIPWReducesConfoundingBias(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	naive := naiveBias(data)
+	res, err := EstimateIPW(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipw := math.Abs(res.Estimate - data.TrueEffect)
+
+	// Weighting on X should remove most of the bias from X-driven treatment
+	if ipw >= naive {
+		t.Errorf("IPW bias %.4f not smaller than naive bias %.4f", ipw, naive)
+	}
+}
+
+func TestWeightedEstimands(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	// With tau(x) = 5 + 2x, treated units (high X) gain more than controls
+	for i, x := range data.Column(0) {
+		if data.Treatment[i] == 1 {
+			data.Outcome[i] += 2 * x
+		}
+	}
+
+	var est [4]float64
+	for _, e := range []Estimand{EstimandATE, EstimandATT, EstimandATC} {
+		res, err := EstimateWeighted(data, WeightingOptions{Estimand: e})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Weights) != len(data.X) || res.SE <= 0 {
+			t.Fatalf("estimand %d: invalid result", e)
+		}
+		est[e] = res.Estimate
+	}
+	if !(est[EstimandATT] > est[EstimandATE] && est[EstimandATE] > est[EstimandATC]) {
+		t.Errorf("expected ATT > ATE > ATC, got %.3f, %.3f, %.3f", est[EstimandATT], est[EstimandATE], est[EstimandATC])
+	}
+
+	// The float shortcut matches the ATE from the weighting pipeline
+	data = GenerateCausalData(500, 1)
+	weighted, _ := EstimateWeighted(data, WeightingOptions{})
+	if ipwRes, _ := EstimateIPW(data); ipwRes.Estimate != weighted.Estimate {
+		t.Error("EstimateIPW disagrees with EstimateWeighted")
+	}
+}
+
+func TestTrimmingAndTruncation(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+	base, _ := EstimateWeighted(data, WeightingOptions{})
+	scores := EstimatePropensityScores(data)
+
+	trimmed, err := EstimateWeighted(data, WeightingOptions{Trim: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want int
+	for _, p := range scores {
+		if p < 0.1 || p > 0.9 {
+			want++
+		}
+	}
+	if trimmed.Trimmed != want || trimmed.N != len(scores)-want {
+		t.Errorf("trimmed %d units (N=%d), want %d", trimmed.Trimmed, trimmed.N, want)
+	}
+
+	truncated, err := EstimateWeighted(data, WeightingOptions{Truncate: 0.95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Roughly 5% of weights sit above their 95th percentile
+	if truncated.Truncated < 200 || truncated.Truncated > 300 {
+		t.Errorf("truncated %d weights, want about 250", truncated.Truncated)
+	}
+	var top, topBase float64
+	for i, w := range truncated.Weights {
+		top = math.Max(top, w)
+		topBase = math.Max(topBase, base.Weights[i])
+	}
+	if top >= topBase {
+		t.Errorf("largest weight %.2f not reduced from %.2f", top, topBase)
+	}
+	// Capping extreme weights trades a little bias for less variance
+	if truncated.SE >= base.SE {
+		t.Errorf("truncated SE %.4f not below untruncated %.4f", truncated.SE, base.SE)
+	}
+}
+
+func TestStabilizedWeights(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+	base, _ := EstimateWeighted(data, WeightingOptions{})
+	res, err := EstimateWeighted(data, WeightingOptions{Stabilize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Normalisation within arms makes the estimate scale-free
+	if math.Abs(res.Estimate-base.Estimate) > 1e-9 {
+		t.Errorf("stabilized estimate %.6f differs from %.6f", res.Estimate, base.Estimate)
+	}
+
+	var sum float64
+	for _, w := range res.Weights {
+		sum += w
+	}
+	if mean := sum / float64(len(res.Weights)); math.Abs(mean-1) > 0.2 {
+		t.Errorf("stabilized weights average %.3f, want about 1", mean)
+	}
+	for g, s := range res.Summary {
+		if !(s.Min <= s.Q1 && s.Q1 <= s.Median && s.Median <= s.Q3 && s.Q3 <= s.Max) || s.SD <= 0 {
+			t.Errorf("arm %d: inconsistent summary %+v", g, s)
+		}
+		if s.Max >= base.Summary[g].Max {
+			t.Errorf("arm %d: stabilized max %.2f not below %.2f", g, s.Max, base.Summary[g].Max)
+		}
+	}
+}
+
+func TestEffectiveSampleSize(t *testing.T) {
+	if ess := effectiveSampleSize([]float64{1, 1, 1, 1}); ess != 4 {
+		t.Errorf("equal weights ESS %.3f, want 4", ess)
+	}
+
+	data := GenerateCausalData(3000, 5)
+	base, _ := EstimateWeighted(data, WeightingOptions{})
+	stable, _ := EstimateWeighted(data, WeightingOptions{Stabilize: true})
+	capped, _ := EstimateWeighted(data, WeightingOptions{Truncate: 0.9})
+
+	var counts [2]float64
+	for _, g := range data.Treatment {
+		counts[g]++
+	}
+	for g, s := range base.Summary {
+		// Rescaling leaves the ESS alone, and capping weights raises it
+		if s.ESS <= 1 || s.ESS > counts[g] || math.Abs(stable.Summary[g].ESS-s.ESS) > 1e-6 {
+			t.Errorf("arm %d: ESS %.1f of %.0f units, stabilized %.1f", g, s.ESS, counts[g], stable.Summary[g].ESS)
+		}
+		if capped.Summary[g].ESS <= s.ESS || capped.Summary[g].MaxRatio >= s.MaxRatio || s.MaxRatio <= 1 {
+			t.Errorf("arm %d: truncation ESS %.1f vs %.1f, max ratio %.2f vs %.2f",
+				g, capped.Summary[g].ESS, s.ESS, capped.Summary[g].MaxRatio, s.MaxRatio)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// IVData holds synthetic data for instrumental variable designs. An
+// unobserved confounder drives both Treatment and Outcome, so adjusting for
+// X alone is biased, while Z shifts Treatment without affecting Outcome.
+type IVData struct {
+	Z          []float64 // binary instrument (0 or 1)
+	X          []float64 // exogenous covariate
+	Treatment  []float64 // endogenous treatment
+	Outcome    []float64 // observed outcome
+	TrueEffect float64   // for testing
+}
+
+// ErrNoInstrument is returned when an IV analysis is asked of data
+// without an instrument
+var ErrNoInstrument = errors.New("causalinference: data has no instrument")
+
+// InstrumentData returns the data as an IV design, with d.Instrument as Z
+// and the first covariate as X, for Estimate2SLS and EstimateWald
+func (d *CausalData) InstrumentData() (*IVData, error) {
+	if d.Instrument == nil {
+		return nil, ErrNoInstrument
+	}
+	if len(d.Instrument) != len(d.Outcome) {
+		return nil, ErrLengthMismatch
+	}
+	iv := &IVData{
+		Z:          d.Instrument,
+		X:          d.Column(0),
+		Treatment:  make([]float64, len(d.Treatment)),
+		Outcome:    d.Outcome,
+		TrueEffect: d.TrueEffect,
+	}
+	for i, t := range d.Treatment {
+		iv.Treatment[i] = float64(t)
+	}
+	return iv, nil
+}
+
+// GenerateIVData creates synthetic data with an endogenous treatment and a
+// valid binary instrument
+func GenerateIVData(n int, seed int64) *IVData {
+	rng := rand.New(rand.NewSource(seed))
+
+	data := &IVData{
+		Z:          make([]float64, n),
+		X:          make([]float64, n),
+		Treatment:  make([]float64, n),
+		Outcome:    make([]float64, n),
+		TrueEffect: 2.0,
+	}
+
+	for i := 0; i < n; i++ {
+		// Hidden confounder, never stored
+		u := rng.NormFloat64()
+
+		data.X[i] = rng.NormFloat64()
+		if rng.Float64() < 0.5 {
+			data.Z[i] = 1
+		}
+
+		// Treatment responds to the instrument, X and the confounder
+		data.Treatment[i] = data.Z[i] + 0.5*data.X[i] + u + rng.NormFloat64()
+
+		// Outcome depends on treatment, X and the confounder but not on Z
+		data.Outcome[i] = data.X[i] + data.TrueEffect*data.Treatment[i] + 2*u + rng.NormFloat64()
+	}
+
+	return data
+}
+
+// Estimate2SLS estimates the effect of Treatment on Outcome by two-stage
+// least squares, instrumenting Treatment with Z and controlling for X.
+// Standard errors use structural residuals (computed with the observed
+// treatment, not the first-stage fit), matching ivreg in R.
+func Estimate2SLS(data *IVData) (EffectResult, error) {
+	n := len(data.Outcome)
+
+	// First stage: Treatment on instrument and covariate
+	first := make([][]float64, n)
+	for i := range first {
+		first[i] = []float64{1, data.Z[i], data.X[i]}
+	}
+	gamma, err := fitOLS(first, data.Treatment)
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	// Second stage: Outcome on fitted treatment and covariate
+	second := make([][]float64, n)
+	for i := range second {
+		second[i] = []float64{1, dot(first[i], gamma), data.X[i]}
+	}
+	fit, err := regress(second, data.Outcome)
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	var rss float64
+	for i := range data.Outcome {
+		r := data.Outcome[i] - dot([]float64{1, data.Treatment[i], data.X[i]}, fit.coef)
+		rss += r * r
+	}
+	sigma2 := rss / float64(n-len(fit.coef))
+
+	return newEffectResult("2SLS", fit.coef[1], math.Sqrt(sigma2*fit.xtxInv[1][1]), n), nil
+}
+
+// ErrNonBinaryInstrument is returned when an estimator needs a 0/1 instrument
+var ErrNonBinaryInstrument = errors.New("causalinference: instrument must be binary")
+
+// EstimateWald estimates the effect as the ratio of the instrument's effect
+// on Outcome to its effect on Treatment. Z must be binary. The standard
+// error comes from the delta method, including the covariance between the
+// numerator and denominator.
+func EstimateWald(data *IVData) (EffectResult, error) {
+	return waldRatio("Wald", data.Z, data.Treatment, data.Outcome)
+}
+
+// waldRatio computes the Wald estimator and its delta-method standard error
+func waldRatio(method string, z, d, y []float64) (EffectResult, error) {
+	var groups [2]struct{ d, y []float64 }
+	for i, v := range z {
+		if v != 0 && v != 1 {
+			return EffectResult{}, ErrNonBinaryInstrument
+		}
+		g := int(v)
+		groups[g].d = append(groups[g].d, d[i])
+		groups[g].y = append(groups[g].y, y[i])
+	}
+	if len(groups[0].y) < 2 || len(groups[1].y) < 2 {
+		return EffectResult{}, ErrEmptyArm
+	}
+
+	// Reduced form (numerator) and first stage (denominator) with their
+	// sampling variances and covariance, summed over the two independent groups
+	var num, den, varNum, varDen, cov float64
+	for g, sign := range [2]float64{-1, 1} {
+		my, sy := meanAndSE(groups[g].y)
+		md, sd := meanAndSE(groups[g].d)
+		num += sign * my
+		den += sign * md
+		varNum += sy * sy
+		varDen += sd * sd
+		cov += covariance(groups[g].y, groups[g].d) / float64(len(groups[g].y))
+	}
+	if den == 0 {
+		return EffectResult{}, ErrSingularMatrix
+	}
+
+	ratio := num / den
+	variance := (varNum - 2*ratio*cov + ratio*ratio*varDen) / (den * den)
+
+	return newEffectResult(method, ratio, math.Sqrt(variance), len(z)), nil
+}
+
+// covariance returns the sample covariance of two equal-length vectors
+func covariance(a, b []float64) float64 {
+	n := float64(len(a))
+	var ma, mb float64
+	for i := range a {
+		ma += a[i] / n
+		mb += b[i] / n
+	}
+	var s float64
+	for i := range a {
+		s += (a[i] - ma) * (b[i] - mb)
+	}
+	return s / (n - 1)
+}
@@ -0,0 +1,78 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimate2SLS(t *testing.T) {
+	data := GenerateIVData(5000, 42)
+
+	res, err := Estimate2SLS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.2 {
+		t.Errorf("2SLS estimate %.4f too far from true effect %.1f", res.Estimate, data.TrueEffect)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v does not cover the true effect", res.CI)
+	}
+
+	// OLS ignoring the instrument is biased by the hidden confounder
+	x := make([][]float64, len(data.Outcome))
+	for i := range x {
+		x[i] = []float64{1, data.Treatment[i], data.X[i]}
+	}
+	ols, err := fitOLS(x, data.Outcome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ols[1]-data.TrueEffect) < math.Abs(res.Estimate-data.TrueEffect) {
+		t.Error("expected OLS to be more biased than 2SLS")
+	}
+}
+
+func TestEstimateWald(t *testing.T) {
+	data := GenerateIVData(5000, 42)
+
+	res, err := EstimateWald(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("Wald CI %v does not cover the true effect %.1f", res.CI, data.TrueEffect)
+	}
+
+	// A non-binary instrument is rejected
+	data.Z[0] = 0.5
+	if _, err := EstimateWald(data); err != ErrNonBinaryInstrument {
+		t.Errorf("expected ErrNonBinaryInstrument, got %v", err)
+	}
+}
+
+func TestInstrumentData(t *testing.T) {
+	if _, err := GenerateCausalData(100, 1).InstrumentData(); err != ErrNoInstrument {
+		t.Errorf("error %v, want ErrNoInstrument", err)
+	}
+
+	// Under hidden confounding the instrument recovers the effect that
+	// adjustment for X misses
+	data := GenerateCausalData(20000, 3, WithInstrument(0.6), WithHiddenConfounding(0.5))
+	iv, err := data.InstrumentData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Estimate2SLS(iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 4*res.SE {
+		t.Errorf("2SLS %.3f (SE %.3f), want %.1f", res.Estimate, res.SE, data.TrueEffect)
+	}
+	ols, _ := EstimateRegressionAdjustment(data, RegressionOptions{})
+	if math.Abs(ols.Estimate-data.TrueEffect) < math.Abs(res.Estimate-data.TrueEffect) {
+		t.Errorf("adjustment %.3f no more biased than 2SLS %.3f", ols.Estimate, res.Estimate)
+	}
+}
@@ -0,0 +1,73 @@
+package causalinference
+
+import "math"
+
+// JackknifeResult holds jackknife replicates and the resulting variance
+// estimate. The embedded EffectResult has the original estimate, the
+// jackknife standard error and a normal interval.
+type JackknifeResult struct {
+	EffectResult
+	Replicates []float64 // estimate with each unit or cluster left out, in data order
+	Bias       float64   // jackknife bias estimate (G-1)(mean replicate - estimate)
+}
+
+// Jackknife re-runs the estimator with each unit left out in turn and
+// estimates its variance as (G-1)/G times the sum of squared deviations of
+// the G replicates from their mean. When data.Cluster is set, whole
+// clusters are left out instead (the delete-a-group jackknife), which
+// keeps within-cluster dependence intact. It needs only G fits, fewer than
+// a typical bootstrap, but is unreliable for non-smooth statistics such as
+// medians or matching estimators. Fits run in parallel, so the estimator
+// must be safe for concurrent use.
+func Jackknife(estimate Estimator, data *CausalData) (JackknifeResult, error) {
+	original, err := estimate(data)
+	if err != nil {
+		return JackknifeResult{}, err
+	}
+
+	groups := clusterRows(len(data.Outcome), data.Cluster)
+	replicates, errs := leaveGroupOut(estimate, data, groups)
+	for _, err := range errs {
+		if err != nil {
+			return JackknifeResult{}, err
+		}
+	}
+
+	g := float64(len(replicates))
+	var mean float64
+	for _, r := range replicates {
+		mean += r / g
+	}
+	var ss float64
+	for _, r := range replicates {
+		ss += (r - mean) * (r - mean)
+	}
+
+	res := JackknifeResult{
+		EffectResult: newEffectResult(original.Method, original.Estimate, math.Sqrt((g-1)/g*ss), original.N),
+		Replicates:   replicates,
+		Bias:         (g - 1) * (mean - original.Estimate),
+	}
+	res.Scale = original.Scale
+	res.EffectResult = res.tested()
+	return res, nil
+}
+
+// leaveGroupOut runs the estimator once per group with that group's units
+// removed
+func leaveGroupOut(estimate Estimator, data *CausalData, groups [][]int) ([]float64, []error) {
+	n := len(data.Outcome)
+	return parallelEstimates(estimate, len(groups), func(k int) *CausalData {
+		drop := make(map[int]bool, len(groups[k]))
+		for _, i := range groups[k] {
+			drop[i] = true
+		}
+		units := make([]int, 0, n-len(groups[k]))
+		for i := 0; i < n; i++ {
+			if !drop[i] {
+				units = append(units, i)
+			}
+		}
+		return subsetData(data, units)
+	})
+}
@@ -0,0 +1,50 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestJackknife(t *testing.T) {
+	data := GenerateCausalData(300, 8)
+
+	// For a sample mean the jackknife SE is exactly s/sqrt(n)
+	mean := func(d *CausalData) (EffectResult, error) {
+		m, se := meanAndSE(d.Outcome)
+		return newEffectResult("Mean", m, se, len(d.Outcome)), nil
+	}
+	res, err := Jackknife(mean, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, se := meanAndSE(data.Outcome); math.Abs(res.SE-se) > 1e-10 || math.Abs(res.Bias) > 1e-10 {
+		t.Errorf("jackknife SE %.8f (bias %.2g), want %.8f", res.SE, res.Bias, se)
+	}
+
+	welch, _ := EstimateCausalEffect(data)
+	res, _ = Jackknife(EstimateCausalEffect, data)
+	if len(res.Replicates) != 300 || math.Abs(res.SE/welch.SE-1) > 0.05 {
+		t.Errorf("jackknife SE %.4f, Welch %.4f", res.SE, welch.SE)
+	}
+
+	// Deleting whole clusters picks up a shared cluster shock
+	rng := rand.New(rand.NewSource(8))
+	data.Cluster = make([]int, len(data.Outcome))
+	shocks := make([]float64, 30)
+	for g := range shocks {
+		shocks[g] = 2 * rng.NormFloat64()
+	}
+	for i := range data.Outcome {
+		g := i % 30
+		data.Cluster[i] = g
+		data.Treatment[i] = g % 2
+		data.Outcome[i] = shocks[g] + rng.NormFloat64()
+	}
+	blocked, _ := Jackknife(EstimateCausalEffect, data)
+	data.Cluster = nil
+	units, _ := Jackknife(EstimateCausalEffect, data)
+	if len(blocked.Replicates) != 30 || blocked.SE < 2*units.SE {
+		t.Errorf("blocked SE %.4f over %d clusters, unit SE %.4f", blocked.SE, len(blocked.Replicates), units.SE)
+	}
+}
@@ -0,0 +1,145 @@
+package causalinference
+
+import "sort"
+
+// kdTree answers nearest-neighbor queries over a fixed set of points and
+// supports removing points, as needed for matching without replacement
+type kdTree struct {
+	points  [][]float64 // points[j] is the location of unit j
+	root    *kdNode
+	removed map[int]bool
+}
+
+type kdNode struct {
+	unit        int
+	axis        int
+	left, right *kdNode
+	alive       int // points still available in this subtree
+}
+
+// newKDTree builds a balanced tree over the given units
+func newKDTree(points [][]float64, units []int) *kdTree {
+	ids := append([]int(nil), units...)
+	t := &kdTree{points: points, removed: make(map[int]bool)}
+	t.root = t.build(ids, 0)
+	return t
+}
+
+func (t *kdTree) build(ids []int, depth int) *kdNode {
+	if len(ids) == 0 {
+		return nil
+	}
+	axis := depth % len(t.points[ids[0]])
+	sort.Slice(ids, func(a, b int) bool {
+		return t.points[ids[a]][axis] < t.points[ids[b]][axis]
+	})
+	mid := len(ids) / 2
+	return &kdNode{
+		unit:  ids[mid],
+		axis:  axis,
+		left:  t.build(ids[:mid], depth+1),
+		right: t.build(ids[mid+1:], depth+1),
+		alive: len(ids),
+	}
+}
+
+// nearest returns up to k available units closest to q, nearest first
+func (t *kdTree) nearest(q []float64, k int) []int {
+	best := &neighborHeap{k: k}
+	t.search(t.root, q, best)
+	return best.units()
+}
+
+func (t *kdTree) search(n *kdNode, q []float64, best *neighborHeap) {
+	if n == nil || n.alive == 0 {
+		return
+	}
+
+	if !t.removed[n.unit] {
+		best.offer(n.unit, squaredDistance(q, t.points[n.unit]))
+	}
+
+	// Descend the side containing q first, then the other if it could help
+	diff := q[n.axis] - t.points[n.unit][n.axis]
+	near, far := n.left, n.right
+	if diff > 0 {
+		near, far = n.right, n.left
+	}
+	t.search(near, q, best)
+	if !best.full() || diff*diff < best.worst() {
+		t.search(far, q, best)
+	}
+}
+
+// remove marks unit j as used so later queries skip it
+func (t *kdTree) remove(j int) {
+	if t.removed[j] {
+		return
+	}
+	t.removed[j] = true
+
+	// Walk down to j, decrementing the live counts along the path
+	n := t.root
+	for n != nil {
+		n.alive--
+		if n.unit == j {
+			return
+		}
+		if t.points[j][n.axis] < t.points[n.unit][n.axis] {
+			n = n.left
+		} else if t.points[j][n.axis] > t.points[n.unit][n.axis] {
+			n = n.right
+		} else if t.contains(n.left, j) {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+}
+
+// contains reports whether unit j sits in the subtree rooted at n
+func (t *kdTree) contains(n *kdNode, j int) bool {
+	if n == nil {
+		return false
+	}
+	return n.unit == j || t.contains(n.left, j) || t.contains(n.right, j)
+}
+
+// neighborHeap keeps the k closest candidates seen so far, sorted by distance
+type neighborHeap struct {
+	k     int
+	ids   []int
+	dists []float64
+}
+
+func (h *neighborHeap) full() bool { return len(h.ids) >= h.k }
+
+func (h *neighborHeap) worst() float64 { return h.dists[len(h.dists)-1] }
+
+func (h *neighborHeap) offer(id int, d float64) {
+	if h.full() && d >= h.worst() {
+		return
+	}
+	pos := sort.SearchFloat64s(h.dists, d)
+	h.ids = append(h.ids, 0)
+	h.dists = append(h.dists, 0)
+	copy(h.ids[pos+1:], h.ids[pos:])
+	copy(h.dists[pos+1:], h.dists[pos:])
+	h.ids[pos], h.dists[pos] = id, d
+	if len(h.ids) > h.k {
+		h.ids = h.ids[:h.k]
+		h.dists = h.dists[:h.k]
+	}
+}
+
+func (h *neighborHeap) units() []int { return h.ids }
+
+// squaredDistance returns the squared Euclidean distance between a and b
+func squaredDistance(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		d := a[i] - b[i]
+		s += d * d
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package causalinference
+
+import "math"
+
+// KernelMatchOptions configures kernel matching
+type KernelMatchOptions struct {
+	Kernel    Kernel   // kernel on the propensity score distance; defaults to triangular
+	Bandwidth float64  // kernel bandwidth on the score scale; 0 means 0.06, as in psmatch2
+	Estimand  Estimand // target population; defaults to ATT
+}
+
+// KernelMatchResult holds a kernel matching estimate and the weights it
+// implies for the comparison arm
+type KernelMatchResult struct {
+	EffectResult
+	// Weights holds each unit's total weight as a comparison unit, summed
+	// over the focal units it serves and scaled so that each focal unit
+	// contributes one; focal units have weight 1 and unused units 0
+	Weights   []float64
+	Unmatched []int // focal units with no comparison unit within the bandwidth
+	// EffectiveControls is Kish's effective sample size of the comparison
+	// weights when the focal units are treated
+	EffectiveControls float64
+}
+
+// MatchKernel estimates the effect by kernel matching on the propensity
+// score: each focal unit's counterfactual is the kernel-weighted average
+// outcome of all units in the other arm, with weights K((e_i - e_j)/h).
+// For the ATT the focal units are the treated, for the ATC the controls,
+// and the ATE averages the two by arm size. Focal units with zero total
+// kernel weight, possible with compact kernels, are dropped and listed in
+// Unmatched. The standard error treats the matched differences as
+// independent, ignoring the reuse of comparison units.
+func MatchKernel(data *CausalData, opts KernelMatchOptions) (KernelMatchResult, error) {
+	if err := requireBothArms(data); err != nil {
+		return KernelMatchResult{}, err
+	}
+	scores, err := propensityScores(data)
+	if err != nil {
+		return KernelMatchResult{}, err
+	}
+	h := opts.Bandwidth
+	if h <= 0 {
+		h = 0.06
+	}
+
+	var treated, controls []int
+	for i, t := range data.Treatment {
+		if t == 1 {
+			treated = append(treated, i)
+		} else {
+			controls = append(controls, i)
+		}
+	}
+
+	res := KernelMatchResult{Weights: make([]float64, len(scores))}
+	var diffs []float64
+	var focalCount [2]float64
+	estimand := opts.Estimand.or(EstimandATT)
+	if estimand == EstimandATT || estimand == EstimandATE {
+		d, u := kernelMatchArm(data, scores, treated, controls, opts.Kernel, h, 1, res.Weights)
+		diffs, res.Unmatched = append(diffs, d...), append(res.Unmatched, u...)
+		focalCount[1] = float64(len(d))
+	}
+	if estimand == EstimandATC || estimand == EstimandATE {
+		d, u := kernelMatchArm(data, scores, controls, treated, opts.Kernel, h, -1, res.Weights)
+		diffs, res.Unmatched = append(diffs, d...), append(res.Unmatched, u...)
+		focalCount[0] = float64(len(d))
+	}
+	if len(diffs) < 2 {
+		return KernelMatchResult{}, ErrEmptyArm
+	}
+
+	// Weighting every difference equally averages the two arms by size
+	estimate, se := meanAndSE(diffs)
+	res.EffectResult = newEffectResult("KernelMatching", estimate, se, int(focalCount[0]+focalCount[1]))
+
+	var sumW, sumW2 float64
+	for _, j := range controls {
+		sumW += res.Weights[j]
+		sumW2 += res.Weights[j] * res.Weights[j]
+	}
+	if sumW2 > 0 {
+		res.EffectiveControls = sumW * sumW / sumW2
+	}
+	return res, nil
+}
+
+// kernelMatchArm returns sign*(Y_i - counterfactual_i) for each focal unit
+// with a nonzero kernel total, plus the unmatched focal units, and adds the
+// implied comparison weights into weights
+func kernelMatchArm(data *CausalData, scores []float64, focal, pool []int, kernel Kernel, h, sign float64, weights []float64) ([]float64, []int) {
+	var diffs []float64
+	var unmatched []int
+	k := make([]float64, len(pool))
+	for _, i := range focal {
+		var total, sum float64
+		for m, j := range pool {
+			k[m] = kernel.weight((scores[i] - scores[j]) / h)
+			total += k[m]
+			sum += k[m] * data.Outcome[j]
+		}
+		if total == 0 || math.IsNaN(total) {
+			unmatched = append(unmatched, i)
+			continue
+		}
+		for m, j := range pool {
+			weights[j] += k[m] / total
+		}
+		weights[i] = 1
+		diffs = append(diffs, sign*(data.Outcome[i]-sum/total))
+	}
+	return diffs, unmatched
+}
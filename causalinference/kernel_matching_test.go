@@ -0,0 +1,45 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKernelMatching(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+	naive := naiveBias(data)
+
+	for _, k := range []Kernel{KernelEpanechnikov, KernelGaussian} {
+		res, err := MatchKernel(data, KernelMatchOptions{Kernel: k, Bandwidth: 0.03})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bias := math.Abs(res.Estimate - data.TrueEffect); bias >= naive {
+			t.Errorf("kernel %d: bias %.4f not below naive %.4f", k, bias, naive)
+		}
+
+		// Each matched treated unit spreads a total weight of one over controls
+		var treatedMatched, controlWeight float64
+		for i, w := range res.Weights {
+			if data.Treatment[i] == 1 {
+				treatedMatched += w
+			} else {
+				controlWeight += w
+			}
+		}
+		if math.Abs(treatedMatched-controlWeight) > 1e-6 || res.EffectiveControls <= 0 {
+			t.Errorf("kernel %d: treated weight %.2f, control weight %.2f", k, treatedMatched, controlWeight)
+		}
+		if k == KernelGaussian && len(res.Unmatched) != 0 {
+			t.Errorf("Gaussian kernel left %d units unmatched", len(res.Unmatched))
+		}
+	}
+
+	ate, err := MatchKernel(data, KernelMatchOptions{Kernel: KernelGaussian, Estimand: EstimandATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ate.N != len(data.X) {
+		t.Errorf("ATE used %d units, want %d", ate.N, len(data.X))
+	}
+}
@@ -0,0 +1,21 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateLATE(t *testing.T) {
+	data := GenerateNoncomplianceData(10000, 42)
+
+	res, err := EstimateLATE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueLATE || res.CI[1] < data.TrueLATE {
+		t.Errorf("CI %v does not cover %.1f", res.CI, data.TrueLATE)
+	}
+	if math.Abs(res.ComplierShare-0.6) > 0.03 {
+		t.Errorf("complier share %.3f, want about 0.6", res.ComplierShare)
+	}
+}
@@ -0,0 +1,83 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrUnweightedLearner is returned when an estimator needs a WeightedLearner
+var ErrUnweightedLearner = errors.New("causalinference: learner does not support weights")
+
+// Learner fits a prediction model from feature rows and targets. Estimators
+// that need nuisance models (outcome regressions, propensity models) accept
+// a Learner so users can plug in their own algorithms.
+type Learner interface {
+	Fit(x [][]float64, y []float64) (Model, error)
+}
+
+// Model is a fitted Learner
+type Model interface {
+	Predict(x []float64) float64
+}
+
+// WeightedLearner is a Learner that can also fit with per-unit weights
+type WeightedLearner interface {
+	Learner
+	FitWeighted(x [][]float64, y, w []float64) (Model, error)
+}
+
+// LinearLearner fits ordinary least squares with an intercept
+type LinearLearner struct{}
+
+// Fit implements Learner
+func (LinearLearner) Fit(x [][]float64, y []float64) (Model, error) {
+	coef, err := fitOLS(withIntercept(x), y)
+	if err != nil {
+		return nil, err
+	}
+	return linearModel{coef: coef}, nil
+}
+
+// FitWeighted implements WeightedLearner with weighted least squares
+func (LinearLearner) FitWeighted(x [][]float64, y, w []float64) (Model, error) {
+	xs := withIntercept(x)
+	ys := make([]float64, len(y))
+	for i := range xs {
+		s := math.Sqrt(w[i])
+		for j := range xs[i] {
+			xs[i][j] *= s
+		}
+		ys[i] = y[i] * s
+	}
+
+	coef, err := leastSquaresQR(xs, ys)
+	if err != nil {
+		return nil, err
+	}
+	return linearModel{coef: coef}, nil
+}
+
+type linearModel struct{ coef []float64 }
+
+func (m linearModel) Predict(x []float64) float64 {
+	return m.coef[0] + dot(m.coef[1:], x)
+}
+
+// LogisticLearner fits a logistic regression with an intercept and predicts
+// probabilities. Targets must be 0 or 1.
+type LogisticLearner struct{}
+
+// Fit implements Learner
+func (LogisticLearner) Fit(x [][]float64, y []float64) (Model, error) {
+	coef, err := fitLogistic(withIntercept(x), y)
+	if err != nil {
+		return nil, err
+	}
+	return logisticModel{coef: coef}, nil
+}
+
+type logisticModel struct{ coef []float64 }
+
+func (m logisticModel) Predict(x []float64) float64 {
+	return sigmoid(m.coef[0] + dot(m.coef[1:], x))
+}
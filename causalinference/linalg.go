@@ -0,0 +1,326 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingularMatrix is returned when a linear system has no unique solution
+var ErrSingularMatrix = errors.New("causalinference: singular matrix")
+
+// solveLinear solves a*x = b with Gaussian elimination and partial pivoting
+func solveLinear(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+
+	// Work on an augmented copy so the inputs are left untouched
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = make([]float64, n+1)
+		copy(m[i], a[i])
+		m[i][n] = b[i]
+	}
+
+	for col := 0; col < n; col++ {
+		// Pick the largest pivot in this column for stability
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, ErrSingularMatrix
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for r := col + 1; r < n; r++ {
+			f := m[r][col] / m[col][col]
+			for c := col; c <= n; c++ {
+				m[r][c] -= f * m[col][c]
+			}
+		}
+	}
+
+	// Back substitution
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := m[r][n]
+		for c := r + 1; c < n; c++ {
+			s -= m[r][c] * x[c]
+		}
+		x[r] = s / m[r][r]
+	}
+
+	return x, nil
+}
+
+// invertMatrix returns the inverse of a square matrix via Gauss-Jordan
+// elimination
+func invertMatrix(a [][]float64) ([][]float64, error) {
+	n := len(a)
+
+	// Augment with the identity matrix
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, ErrSingularMatrix
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		// Normalise the pivot row, then clear the column everywhere else
+		p := m[col][col]
+		for c := range m[col] {
+			m[col][c] /= p
+		}
+		for r := 0; r < n; r++ {
+			if r == col || m[r][col] == 0 {
+				continue
+			}
+			f := m[r][col]
+			for c := range m[r] {
+				m[r][c] -= f * m[col][c]
+			}
+		}
+	}
+
+	inv := make([][]float64, n)
+	for i := range m {
+		inv[i] = m[i][n:]
+	}
+
+	return inv, nil
+}
+
+// dot returns the inner product of two equal-length vectors
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// leastSquaresQR minimises ||x*b - y|| using a Householder QR factorisation,
+// which avoids squaring the condition number as the normal equations do.
+func leastSquaresQR(x [][]float64, y []float64) ([]float64, error) {
+	n, p := len(x), len(x[0])
+	if n < p {
+		return nil, ErrSingularMatrix
+	}
+
+	// Copy into column-major storage so each reflection touches contiguous memory
+	a := make([][]float64, p)
+	for j := range a {
+		a[j] = make([]float64, n)
+		for i := range x {
+			a[j][i] = x[i][j]
+		}
+	}
+	b := make([]float64, n)
+	copy(b, y)
+
+	for k := 0; k < p; k++ {
+		// Householder vector for column k below the diagonal
+		var norm float64
+		for i := k; i < n; i++ {
+			norm += a[k][i] * a[k][i]
+		}
+		norm = math.Sqrt(norm)
+		if norm < 1e-12 {
+			return nil, ErrSingularMatrix
+		}
+		if a[k][k] > 0 {
+			norm = -norm
+		}
+		v := make([]float64, n-k)
+		for i := k; i < n; i++ {
+			v[i-k] = a[k][i]
+		}
+		v[0] -= norm
+		vv := dot(v, v)
+
+		// Apply the reflection to the remaining columns and to b
+		for j := k; j < p; j++ {
+			f := 2 * dot(v, a[j][k:]) / vv
+			for i := k; i < n; i++ {
+				a[j][i] -= f * v[i-k]
+			}
+		}
+		f := 2 * dot(v, b[k:]) / vv
+		for i := k; i < n; i++ {
+			b[i] -= f * v[i-k]
+		}
+	}
+
+	// Solve the upper-triangular system R b = Q'y
+	coef := make([]float64, p)
+	for r := p - 1; r >= 0; r-- {
+		s := b[r]
+		for c := r + 1; c < p; c++ {
+			s -= a[c][r] * coef[c]
+		}
+		if math.Abs(a[r][r]) < 1e-12 {
+			return nil, ErrSingularMatrix
+		}
+		coef[r] = s / a[r][r]
+	}
+
+	return coef, nil
+}
+
+// cholesky returns the lower-triangular L with a = L*L' for a symmetric
+// positive definite matrix a
+func cholesky(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := a[i][j]
+			for k := 0; k < j; k++ {
+				s -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if s <= 0 {
+					return nil, ErrSingularMatrix
+				}
+				l[i][i] = math.Sqrt(s)
+			} else {
+				l[i][j] = s / l[j][j]
+			}
+		}
+	}
+
+	return l, nil
+}
+
+// covarianceMatrix returns the sample covariance of the columns of rows
+func covarianceMatrix(rows [][]float64) [][]float64 {
+	n, p := len(rows), len(rows[0])
+	mean := make([]float64, p)
+	for _, row := range rows {
+		for j, v := range row {
+			mean[j] += v / float64(n)
+		}
+	}
+
+	cov := make([][]float64, p)
+	for j := range cov {
+		cov[j] = make([]float64, p)
+	}
+	for _, row := range rows {
+		for j := 0; j < p; j++ {
+			for k := 0; k < p; k++ {
+				cov[j][k] += (row[j] - mean[j]) * (row[k] - mean[k]) / float64(n-1)
+			}
+		}
+	}
+
+	return cov
+}
+
+// transpose returns the transpose of a rectangular matrix
+func transpose(a [][]float64) [][]float64 {
+	t := make([][]float64, len(a[0]))
+	for j := range t {
+		t[j] = make([]float64, len(a))
+		for i := range a {
+			t[j][i] = a[i][j]
+		}
+	}
+	return t
+}
+
+// matVec returns the matrix-vector product a*v
+func matVec(a [][]float64, v []float64) []float64 {
+	out := make([]float64, len(a))
+	for i, row := range a {
+		out[i] = dot(row, v)
+	}
+	return out
+}
+
+// Settings for the Jacobi eigenvalue iteration
+const (
+	jacobiMaxSweeps = 100
+	jacobiTolerance = 1e-12
+)
+
+// symmetricEigen returns the eigenvalues of a symmetric matrix and the
+// matching eigenvectors as the columns of the second result, using cyclic
+// Jacobi rotations. a is not modified.
+func symmetricEigen(a [][]float64) ([]float64, [][]float64) {
+	n := len(a)
+	m := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range m {
+		m[i] = append([]float64(nil), a[i]...)
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < jacobiMaxSweeps; sweep++ {
+		var off float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off < jacobiTolerance*jacobiTolerance {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				// Rotation angle that zeroes m[p][q]
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p] = c*mkp - s*mkq
+					m[k][q] = s*mkp + c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k] = c*mpk - s*mqk
+					m[q][k] = s*mpk + c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = m[i][i]
+	}
+	return values, v
+}
@@ -0,0 +1,57 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolveAndInvert(t *testing.T) {
+	a := [][]float64{{4, 1}, {2, 3}}
+	b := []float64{1, 2}
+
+	x, err := solveLinear(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x[0]-0.1) > 1e-9 || math.Abs(x[1]-0.6) > 1e-9 {
+		t.Errorf("unexpected solution %v", x)
+	}
+
+	inv, err := invertMatrix(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(inv[0][0]-0.3) > 1e-9 || math.Abs(inv[1][0]+0.2) > 1e-9 {
+		t.Errorf("unexpected inverse %v", inv)
+	}
+
+	// A singular system should be reported rather than solved
+	if _, err := solveLinear([][]float64{{1, 2}, {2, 4}}, b); err != ErrSingularMatrix {
+		t.Errorf("expected ErrSingularMatrix, got %v", err)
+	}
+}
+
+func TestSymmetricEigen(t *testing.T) {
+	a := [][]float64{{4, 1, 0.5}, {1, 3, 0.2}, {0.5, 0.2, 2}}
+	values, vectors := symmetricEigen(a)
+
+	// Each column should satisfy a*v = lambda*v with unit length
+	for k, l := range values {
+		v := make([]float64, len(a))
+		for i := range v {
+			v[i] = vectors[i][k]
+		}
+		av := matVec(a, v)
+		for i := range av {
+			if math.Abs(av[i]-l*v[i]) > 1e-9 {
+				t.Fatalf("column %d is not an eigenvector: %v vs %v", k, av, v)
+			}
+		}
+		if math.Abs(dot(v, v)-1) > 1e-9 {
+			t.Errorf("column %d has length %.6f", k, math.Sqrt(dot(v, v)))
+		}
+	}
+	if tr := values[0] + values[1] + values[2]; math.Abs(tr-9) > 1e-9 {
+		t.Errorf("eigenvalues sum to %.6f, want the trace 9", tr)
+	}
+}
@@ -0,0 +1,68 @@
+package causalinference
+
+import "math"
+
+// Convergence settings for iteratively reweighted least squares
+const (
+	irlsMaxIter   = 50
+	irlsTolerance = 1e-8
+)
+
+// sigmoid maps a linear predictor onto a probability
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// fitLogistic fits a logistic regression of y on the rows of x using IRLS.
+// Rows of x should already contain an intercept column if one is wanted.
+func fitLogistic(x [][]float64, y []float64) ([]float64, error) {
+	p := len(x[0])
+	beta := make([]float64, p)
+
+	for iter := 0; iter < irlsMaxIter; iter++ {
+		// Build the weighted normal equations X'WX and X'(y - mu)
+		hess := make([][]float64, p)
+		for j := range hess {
+			hess[j] = make([]float64, p)
+		}
+		grad := make([]float64, p)
+
+		for i, row := range x {
+			mu := sigmoid(dot(row, beta))
+			w := mu * (1 - mu)
+			// Floor the weight so separated points don't make X'WX singular
+			if w < 1e-10 {
+				w = 1e-10
+			}
+			r := y[i] - mu
+			for j := 0; j < p; j++ {
+				grad[j] += row[j] * r
+				for k := j; k < p; k++ {
+					hess[j][k] += w * row[j] * row[k]
+				}
+			}
+		}
+		for j := 0; j < p; j++ {
+			for k := 0; k < j; k++ {
+				hess[j][k] = hess[k][j]
+			}
+		}
+
+		// Newton step
+		step, err := solveLinear(hess, grad)
+		if err != nil {
+			return nil, err
+		}
+
+		var change float64
+		for j := range beta {
+			beta[j] += step[j]
+			change = math.Max(change, math.Abs(step[j]))
+		}
+		if change < irlsTolerance {
+			break
+		}
+	}
+
+	return beta, nil
+}
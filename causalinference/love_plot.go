@@ -0,0 +1,66 @@
+package causalinference
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// LovePoint is one point of a love plot: the balance of a covariate in
+// the unadjusted or the adjusted sample
+type LovePoint struct {
+	Covariate     string  `json:"covariate"`
+	Sample        string  `json:"sample"` // "Unadjusted" or "Adjusted"
+	SMD           float64 `json:"smd"`
+	VarianceRatio float64 `json:"variance_ratio"`
+}
+
+// LovePlotData flattens a balance table into the points of a love plot,
+// two per covariate, with covariates ordered by decreasing absolute
+// unadjusted SMD as in cobalt::love.plot(var.order = "unadjusted")
+func LovePlotData(table []CovariateBalance) []LovePoint {
+	sorted := append([]CovariateBalance(nil), table...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return math.Abs(sorted[a].Unadjusted.SMD) > math.Abs(sorted[b].Unadjusted.SMD)
+	})
+
+	points := make([]LovePoint, 0, 2*len(sorted))
+	for _, c := range sorted {
+		points = append(points,
+			LovePoint{c.Covariate, "Unadjusted", c.Unadjusted.SMD, c.Unadjusted.VarianceRatio},
+			LovePoint{c.Covariate, "Adjusted", c.Adjusted.SMD, c.Adjusted.VarianceRatio})
+	}
+	return points
+}
+
+// WriteLovePlotJSON writes the points as a JSON array
+func WriteLovePlotJSON(w io.Writer, points []LovePoint) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(points)
+}
+
+// WriteLovePlotCSV writes the points as CSV with a header row, ready for
+// read.csv and ggplot2 in R
+func WriteLovePlotCSV(w io.Writer, points []LovePoint) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"covariate", "sample", "smd", "variance_ratio"}); err != nil {
+		return err
+	}
+	for _, p := range points {
+		record := []string{
+			p.Covariate,
+			p.Sample,
+			strconv.FormatFloat(p.SMD, 'g', -1, 64),
+			strconv.FormatFloat(p.VarianceRatio, 'g', -1, 64),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,37 @@
+package causalinference
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLovePlotData(t *testing.T) {
+	table := []CovariateBalance{
+		{Covariate: "age", Unadjusted: BalanceStats{SMD: 0.1, VarianceRatio: 1.1}, Adjusted: BalanceStats{SMD: 0.02, VarianceRatio: 1}},
+		{Covariate: "income", Unadjusted: BalanceStats{SMD: -0.6, VarianceRatio: 2}, Adjusted: BalanceStats{SMD: 0.05, VarianceRatio: 1.2}},
+	}
+	points := LovePlotData(table)
+	if len(points) != 4 || points[0].Covariate != "income" || points[0].Sample != "Unadjusted" || points[3].Covariate != "age" {
+		t.Errorf("unexpected order: %+v", points)
+	}
+
+	var js bytes.Buffer
+	if err := WriteLovePlotJSON(&js, points); err != nil {
+		t.Fatal(err)
+	}
+	var back []LovePoint
+	if err := json.Unmarshal(js.Bytes(), &back); err != nil || len(back) != 4 || back[1] != points[1] {
+		t.Errorf("JSON round trip failed: %v %+v", err, back)
+	}
+
+	var csv bytes.Buffer
+	if err := WriteLovePlotCSV(&csv, points); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 5 || lines[0] != "covariate,sample,smd,variance_ratio" || lines[1] != "income,Unadjusted,-0.6,2" {
+		t.Errorf("unexpected CSV:\n%s", csv.String())
+	}
+}
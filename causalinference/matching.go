@@ -0,0 +1,369 @@
+package causalinference
+
+import (
+	"math"
+	"sort"
+)
+
+// Distance selects how closeness between units is measured when matching
+type Distance int
+
+const (
+	// DistancePropensity matches on the estimated propensity score
+	DistancePropensity Distance = iota
+	// DistanceMahalanobis matches on the covariates scaled by their inverse covariance
+	DistanceMahalanobis
+)
+
+// MatchOptions configures nearest-neighbor matching
+type MatchOptions struct {
+	Replace  bool     // allow a unit to be reused as a match for several focal units
+	Ratio    int      // matches for each focal unit; 0 means 1
+	Distance Distance // distance measure; defaults to the propensity score
+	Caliper  float64  // max propensity score distance for a match; 0 disables
+	Estimand Estimand // target population; defaults to ATT
+}
+
+// MatchedSet is a group of treated and control units matched together.
+// Nearest-neighbor matching produces one focal unit with its matches from
+// the other arm.
+type MatchedSet struct {
+	Treated  []int // indices of treated units in the set
+	Controls []int // indices of control units in the set
+}
+
+// MatchResult holds the matched sample together with the estimated effect
+type MatchResult struct {
+	EffectResult
+	Sets            []MatchedSet // matched sets that contribute to the estimate
+	Unmatched       []int        // focal units dropped for lack of a match within the caliper
+	MatchedTreated  int          // distinct treated units used
+	MatchedControls int          // distinct controls used
+	// EffectiveControls is Kish's effective sample size of the control
+	// weights implied by the matching, which shrinks as controls are reused
+	EffectiveControls float64
+}
+
+// MatchNearestNeighbor matches each focal unit with its nearest unit(s) from
+// the other arm and estimates the effect from the matched differences. For
+// the ATT the focal units are the treated, for the ATC the controls, and
+// for the ATE both arms are matched in turn and the two effects averaged
+// by arm size.
+// Without replacement, propensity matching processes focal units greedily
+// from the hardest to match (furthest toward the other arm's scores) first,
+// as MatchIt does; Mahalanobis matching processes them in data order.
+// With a caliper, matches further than Caliper on the propensity score are
+// rejected, and focal units left without any match are reported in
+// Unmatched. For Mahalanobis matching the caliper is applied to the nearest
+// Mahalanobis neighbors, as MatchIt does.
+// The standard error treats matched differences as independent, which
+// ignores the extra variance from reusing units when Replace is set.
+func MatchNearestNeighbor(data *CausalData, opts MatchOptions) (MatchResult, error) {
+	var treated, controls []int
+	for i, t := range data.Treatment {
+		if t == 1 {
+			treated = append(treated, i)
+		} else {
+			controls = append(controls, i)
+		}
+	}
+	if len(treated) == 0 || len(controls) == 0 {
+		return MatchResult{}, ErrEmptyArm
+	}
+
+	var scores []float64
+	if opts.Distance == DistancePropensity || opts.Caliper > 0 {
+		var err error
+		scores, err = propensityScores(data)
+		if err != nil {
+			return MatchResult{}, err
+		}
+	}
+	var points [][]float64
+	if opts.Distance == DistanceMahalanobis {
+		var err error
+		points, err = mahalanobisPoints(covariateRows(data))
+		if err != nil {
+			return MatchResult{}, err
+		}
+	}
+
+	var sets []MatchedSet
+	var unmatched []int
+	estimand := opts.Estimand.or(EstimandATT)
+	if estimand == EstimandATT || estimand == EstimandATE {
+		s, u := matchFocal(treated, controls, scores, points, opts, true)
+		sets, unmatched = append(sets, s...), append(unmatched, u...)
+	}
+	if estimand == EstimandATC || estimand == EstimandATE {
+		s, u := matchFocal(controls, treated, scores, points, opts, false)
+		sets, unmatched = append(sets, s...), append(unmatched, u...)
+	}
+
+	res, err := matchedEffect(data, sets, estimand)
+	if err != nil {
+		return MatchResult{}, err
+	}
+	res.Method = "NearestNeighborMatching"
+	// Report dropped units in data order
+	sort.Ints(unmatched)
+	res.Unmatched = unmatched
+	return res, nil
+}
+
+// matchFocal finds matches from pool for each focal unit. focalTreated says
+// which arm the focal units belong to, so sets are filled in the right order.
+func matchFocal(focal, pool []int, scores []float64, points [][]float64, opts MatchOptions, focalTreated bool) ([]MatchedSet, []int) {
+	ratio := opts.Ratio
+	if ratio < 1 {
+		ratio = 1
+	}
+	focal = append([]int(nil), focal...)
+
+	var index neighborIndex
+	switch opts.Distance {
+	case DistanceMahalanobis:
+		index = &mahalanobisIndex{points: points, tree: newKDTree(points, pool)}
+	default:
+		index = newScoreIndex(scores, pool)
+
+		// Match the hardest-to-match units first: the highest scores among
+		// treated units, the lowest among controls
+		sort.Slice(focal, func(a, b int) bool {
+			if focalTreated {
+				return scores[focal[a]] > scores[focal[b]]
+			}
+			return scores[focal[a]] < scores[focal[b]]
+		})
+	}
+
+	var sets []MatchedSet
+	var unmatched []int
+	for _, i := range focal {
+		matched := index.nearest(i, ratio)
+		if opts.Caliper > 0 {
+			kept := matched[:0]
+			for _, j := range matched {
+				if math.Abs(scores[i]-scores[j]) <= opts.Caliper {
+					kept = append(kept, j)
+				}
+			}
+			matched = kept
+		}
+		if len(matched) == 0 {
+			unmatched = append(unmatched, i)
+			continue
+		}
+		if !opts.Replace {
+			for _, j := range matched {
+				index.remove(j)
+			}
+		}
+		if focalTreated {
+			sets = append(sets, MatchedSet{Treated: []int{i}, Controls: matched})
+		} else {
+			sets = append(sets, MatchedSet{Treated: matched, Controls: []int{i}})
+		}
+	}
+	return sets, unmatched
+}
+
+// matchedEffect averages within-set differences in means. Each set is
+// weighted by its treated units for the ATT, its controls for the ATC and
+// all of its units for the ATE.
+func matchedEffect(data *CausalData, sets []MatchedSet, estimand Estimand) (MatchResult, error) {
+	if len(sets) == 0 {
+		return MatchResult{}, ErrEmptyArm
+	}
+
+	diffs := make([]float64, len(sets))
+	setWeights := make([]float64, len(sets))
+	treatedUsed := make(map[int]bool)
+	controlWeights := make(map[int]float64)
+	var total float64
+	for k, s := range sets {
+		switch estimand {
+		case EstimandATC:
+			setWeights[k] = float64(len(s.Controls))
+		case EstimandATE:
+			setWeights[k] = float64(len(s.Treated) + len(s.Controls))
+		default:
+			setWeights[k] = float64(len(s.Treated))
+		}
+		total += setWeights[k]
+
+		var treatedMean, controlMean float64
+		for _, i := range s.Treated {
+			treatedMean += data.Outcome[i] / float64(len(s.Treated))
+			treatedUsed[i] = true
+		}
+		for _, j := range s.Controls {
+			controlMean += data.Outcome[j] / float64(len(s.Controls))
+			controlWeights[j] += float64(len(s.Treated)) / float64(len(s.Controls))
+		}
+		diffs[k] = treatedMean - controlMean
+	}
+
+	var estimate float64
+	for k, d := range diffs {
+		estimate += setWeights[k] * d / total
+	}
+	// Weighted analogue of the standard error of a mean of independent differences
+	se := math.NaN()
+	if m := float64(len(diffs)); m > 1 {
+		var ss float64
+		for k, d := range diffs {
+			w := setWeights[k] / total
+			ss += w * w * (d - estimate) * (d - estimate)
+		}
+		se = math.Sqrt(ss * m / (m - 1))
+	}
+
+	var sumW, sumW2 float64
+	for _, w := range controlWeights {
+		sumW += w
+		sumW2 += w * w
+	}
+
+	return MatchResult{
+		EffectResult:      newEffectResult("Matching", estimate, se, len(treatedUsed)+len(controlWeights)),
+		Sets:              sets,
+		MatchedTreated:    len(treatedUsed),
+		MatchedControls:   len(controlWeights),
+		EffectiveControls: sumW * sumW / sumW2,
+	}, nil
+}
+
+// neighborIndex looks up the closest available controls to a treated unit
+type neighborIndex interface {
+	nearest(i, k int) []int // up to k controls closest to unit i, nearest first
+	remove(j int)           // take control j out of future results
+}
+
+// mahalanobisPoints whitens the covariates so that Euclidean distance between
+// the returned points equals Mahalanobis distance between the originals.
+// With S^-1 = L*L', each point is L'x.
+func mahalanobisPoints(rows [][]float64) ([][]float64, error) {
+	inv, err := invertMatrix(covarianceMatrix(rows))
+	if err != nil {
+		return nil, err
+	}
+	l, err := cholesky(inv)
+	if err != nil {
+		return nil, err
+	}
+
+	p := len(l)
+	points := make([][]float64, len(rows))
+	for i, row := range rows {
+		z := make([]float64, p)
+		for j := 0; j < p; j++ {
+			for k := j; k < p; k++ {
+				z[j] += l[k][j] * row[k]
+			}
+		}
+		points[i] = z
+	}
+	return points, nil
+}
+
+// mahalanobisIndex searches whitened covariates with a k-d tree
+type mahalanobisIndex struct {
+	points [][]float64
+	tree   *kdTree
+}
+
+func (m *mahalanobisIndex) nearest(i, k int) []int { return m.tree.nearest(m.points[i], k) }
+
+func (m *mahalanobisIndex) remove(j int) { m.tree.remove(j) }
+
+// scoreIndex finds the nearest still-available controls on a sorted score
+// line. Removed entries are skipped using path-compressed "next available"
+// pointers in each direction, so greedy matching stays close to O(n log n).
+type scoreIndex struct {
+	scores   []float64   // scores of all units, indexed by unit
+	units    []int       // control indices sorted by score
+	values   []float64   // sorted scores
+	position map[int]int // control index -> position in the sorted order
+	removed  []bool
+	left     []int // left[k] points toward the nearest available position <= k
+	right    []int // right[k] points toward the nearest available position >= k
+}
+
+func newScoreIndex(scores []float64, controls []int) *scoreIndex {
+	units := append([]int(nil), controls...)
+	sort.Slice(units, func(a, b int) bool { return scores[units[a]] < scores[units[b]] })
+
+	n := len(units)
+	idx := &scoreIndex{
+		scores:   scores,
+		units:    units,
+		values:   make([]float64, n),
+		position: make(map[int]int, n),
+		removed:  make([]bool, n),
+		left:     make([]int, n),
+		right:    make([]int, n),
+	}
+	for k, j := range units {
+		idx.values[k] = scores[j]
+		idx.position[j] = k
+		idx.left[k] = k
+		idx.right[k] = k
+	}
+	return idx
+}
+
+// findRight returns the first available position >= k, or len(units) if none
+func (s *scoreIndex) findRight(k int) int {
+	root := k
+	for root < len(s.units) && s.removed[root] {
+		root = s.right[root]
+	}
+	for k < len(s.units) && k != root {
+		next := s.right[k]
+		s.right[k] = root
+		k = next
+	}
+	return root
+}
+
+// findLeft returns the last available position <= k, or -1 if none
+func (s *scoreIndex) findLeft(k int) int {
+	root := k
+	for root >= 0 && s.removed[root] {
+		root = s.left[root]
+	}
+	for k >= 0 && k != root {
+		next := s.left[k]
+		s.left[k] = root
+		k = next
+	}
+	return root
+}
+
+// nearest returns up to k available controls closest to unit i's score
+func (s *scoreIndex) nearest(i, k int) []int {
+	v := s.scores[i]
+	pos := sort.SearchFloat64s(s.values, v)
+	lo, hi := s.findLeft(pos-1), s.findRight(pos)
+
+	var out []int
+	for len(out) < k && (lo >= 0 || hi < len(s.units)) {
+		if hi >= len(s.units) || (lo >= 0 && v-s.values[lo] <= s.values[hi]-v) {
+			out = append(out, s.units[lo])
+			lo = s.findLeft(lo - 1)
+		} else {
+			out = append(out, s.units[hi])
+			hi = s.findRight(hi + 1)
+		}
+	}
+	return out
+}
+
+// remove marks control j as used
+func (s *scoreIndex) remove(j int) {
+	k := s.position[j]
+	s.removed[k] = true
+	s.left[k] = k - 1
+	s.right[k] = k + 1
+}
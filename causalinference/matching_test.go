@@ -0,0 +1,156 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatchNearestNeighborWithReplacement(t *testing.T) {
+	data := GenerateCausalData(4000, 123)
+
+	res, err := MatchNearestNeighbor(data, MatchOptions{Replace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every treated unit gets a match when controls can be reused
+	var treated int
+	for _, v := range data.Treatment {
+		treated += v
+	}
+	if len(res.Sets) != treated {
+		t.Errorf("matched %d treated units, want %d", len(res.Sets), treated)
+	}
+
+	// Matching on the score should beat the confounded difference in means
+	naive := naiveBias(data)
+	if bias := math.Abs(res.Estimate - data.TrueEffect); bias >= naive {
+		t.Errorf("matching bias %.4f not smaller than naive bias %.4f", bias, naive)
+	}
+}
+
+func TestMatchNearestNeighborWithoutReplacement(t *testing.T) {
+	data := GenerateCausalData(1000, 7)
+
+	res, err := MatchNearestNeighbor(data, MatchOptions{Ratio: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No control may appear in more than one matched set
+	seen := make(map[int]bool)
+	for _, s := range res.Sets {
+		if data.Treatment[s.Treated[0]] != 1 {
+			t.Fatalf("unit %d is not treated", s.Treated[0])
+		}
+		for _, j := range s.Controls {
+			if data.Treatment[j] != 0 || seen[j] {
+				t.Fatalf("control %d reused or not a control", j)
+			}
+			seen[j] = true
+		}
+	}
+}
+
+func TestMatchMahalanobis(t *testing.T) {
+	data := GenerateCausalData(2000, 11)
+
+	res, err := MatchNearestNeighbor(data, MatchOptions{Distance: DistanceMahalanobis, Replace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With one covariate, the k-d tree must find the control closest in X
+	for _, s := range res.Sets[:50] {
+		best := -1
+		for j, tr := range data.Treatment {
+			if tr == 0 && (best < 0 || math.Abs(data.X[j][0]-data.X[s.Treated[0]][0]) < math.Abs(data.X[best][0]-data.X[s.Treated[0]][0])) {
+				best = j
+			}
+		}
+		got := math.Abs(data.X[s.Controls[0]][0] - data.X[s.Treated[0]][0])
+		if want := math.Abs(data.X[best][0] - data.X[s.Treated[0]][0]); got > want+1e-12 {
+			t.Fatalf("unit %d matched at distance %f, nearest is %f", s.Treated[0], got, want)
+		}
+	}
+}
+
+func TestKDTreeRemove(t *testing.T) {
+	points := [][]float64{{0, 0}, {1, 0}, {0, 2}, {5, 5}, {1, 1}}
+	tree := newKDTree(points, []int{0, 1, 2, 3, 4})
+
+	if got := tree.nearest([]float64{0.9, 0.9}, 1); got[0] != 4 {
+		t.Fatalf("nearest = %v, want [4]", got)
+	}
+	tree.remove(4)
+	if got := tree.nearest([]float64{0.9, 0.9}, 2); got[0] != 1 || got[1] != 0 {
+		t.Errorf("after removal nearest = %v, want [1 0]", got)
+	}
+}
+
+func TestMatchCaliper(t *testing.T) {
+	data := GenerateCausalData(2000, 5)
+	scores := EstimatePropensityScores(data)
+
+	const caliper = 0.001
+	res, err := MatchNearestNeighbor(data, MatchOptions{Caliper: caliper})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Matched pairs respect the caliper, and every treated unit is accounted for
+	for _, s := range res.Sets {
+		if d := math.Abs(scores[s.Treated[0]] - scores[s.Controls[0]]); d > caliper {
+			t.Fatalf("pair distance %f exceeds caliper", d)
+		}
+	}
+	var treated int
+	for _, v := range data.Treatment {
+		treated += v
+	}
+	if len(res.Unmatched) == 0 {
+		t.Error("expected a tight caliper to drop some treated units")
+	}
+	if res.MatchedTreated+len(res.Unmatched) != treated {
+		t.Errorf("matched %d + unmatched %d != treated %d", res.MatchedTreated, len(res.Unmatched), treated)
+	}
+	if res.MatchedControls != res.MatchedTreated || res.EffectiveControls != float64(res.MatchedControls) {
+		t.Errorf("1:1 matching without replacement should use distinct equally weighted controls")
+	}
+}
+
+func TestMatchEstimands(t *testing.T) {
+	data := GenerateCausalData(3000, 9)
+
+	// For the ATC every control is matched to treated units
+	res, err := MatchNearestNeighbor(data, MatchOptions{Replace: true, Estimand: EstimandATC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range res.Sets {
+		if len(s.Controls) != 1 || len(s.Treated) != 1 || data.Treatment[s.Treated[0]] != 1 {
+			t.Fatalf("unexpected ATC set %+v", s)
+		}
+	}
+	var controls int
+	for _, v := range data.Treatment {
+		controls += 1 - v
+	}
+	if res.MatchedControls != controls {
+		t.Errorf("ATC used %d controls, want all %d", res.MatchedControls, controls)
+	}
+
+	// The ATE sits between the ATT and ATC when both arms are matched
+	ate, err := MatchNearestNeighbor(data, MatchOptions{Replace: true, Estimand: EstimandATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	att, err := MatchNearestNeighbor(data, MatchOptions{Replace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lo, hi := math.Min(att.Estimate, res.Estimate), math.Max(att.Estimate, res.Estimate)
+	if ate.Estimate < lo-1e-9 || ate.Estimate > hi+1e-9 {
+		t.Errorf("ATE %.4f not between ATT %.4f and ATC %.4f", ate.Estimate, att.Estimate, res.Estimate)
+	}
+}
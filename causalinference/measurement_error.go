@@ -0,0 +1,57 @@
+package causalinference
+
+import "math/rand"
+
+// ErrorFreeValues holds the values of a dataset before measurement error
+// was added, for evaluating estimators against what they should have seen
+type ErrorFreeValues struct {
+	X       [][]float64 // true covariates, one row per unit
+	Outcome []float64   // true outcomes
+}
+
+// MeasurementErrorOptions configures AddMeasurementError. Errors are
+// normal with the given standard deviations and independent of everything
+// else, the classical model, unless a differential shift is set.
+type MeasurementErrorOptions struct {
+	CovariateSD float64 // standard deviation of the error added to every covariate
+	OutcomeSD   float64 // standard deviation of the error added to the outcome
+	// CovariateDifferential and OutcomeDifferential are the means of the
+	// errors among treated units, zero among controls, so mismeasurement
+	// depends on treatment as with recall bias; 0 means classical error
+	CovariateDifferential float64
+	OutcomeDifferential   float64
+	Seed                  int64 // seed of the error draws
+}
+
+// AddMeasurementError returns a copy of data whose covariates and outcomes
+// are observed with error, keeping the error-free values in ErrorFree.
+// Classical error in X attenuates regression adjustment toward the
+// confounded comparison, while classical error in the outcome only adds
+// noise; differential error in the outcome biases every estimator by
+// OutcomeDifferential.
+func AddMeasurementError(data *CausalData, opts MeasurementErrorOptions) *CausalData {
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	n := len(data.Outcome)
+	out := subsetData(data, allUnits(n))
+	out.ErrorFree = &ErrorFreeValues{X: data.X, Outcome: data.Outcome}
+	out.Outcome = make([]float64, n)
+
+	for i, row := range data.X {
+		t := float64(data.Treatment[i])
+		noisy := make([]float64, len(row))
+		for j, v := range row {
+			noisy[j] = v + opts.CovariateDifferential*t
+			if opts.CovariateSD > 0 {
+				noisy[j] += opts.CovariateSD * rng.NormFloat64()
+			}
+		}
+		out.X[i] = noisy
+
+		out.Outcome[i] = data.Outcome[i] + opts.OutcomeDifferential*t
+		if opts.OutcomeSD > 0 {
+			out.Outcome[i] += opts.OutcomeSD * rng.NormFloat64()
+		}
+	}
+	return out
+}
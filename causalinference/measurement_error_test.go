@@ -0,0 +1,31 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeasurementError(t *testing.T) {
+	data := GenerateCausalData(5000, 19)
+	clean, err := EstimateRegressionAdjustment(data, RegressionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Classical error in the confounder leaves residual confounding
+	noisyX := AddMeasurementError(data, MeasurementErrorOptions{CovariateSD: 1, Seed: 1})
+	if noisyX.ErrorFree.X[3][0] != data.X[3][0] || noisyX.X[3][0] == data.X[3][0] {
+		t.Fatal("error-free covariates not retained")
+	}
+	attenuated, _ := EstimateRegressionAdjustment(noisyX, RegressionOptions{})
+	if attenuated.Estimate-data.TrueEffect <= 4*clean.SE {
+		t.Errorf("estimate %.3f with noisy X shows no bias", attenuated.Estimate)
+	}
+
+	// Differential outcome error shifts the estimate by its mean
+	shifted := AddMeasurementError(data, MeasurementErrorOptions{OutcomeDifferential: 0.5, Seed: 1})
+	res, _ := EstimateRegressionAdjustment(shifted, RegressionOptions{})
+	if math.Abs(res.Estimate-clean.Estimate-0.5) > 1e-9 {
+		t.Errorf("differential error moved the estimate by %.4f, want 0.5", res.Estimate-clean.Estimate)
+	}
+}
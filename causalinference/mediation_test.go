@@ -0,0 +1,23 @@
+package causalinference
+
+import "testing"
+
+func TestEstimateMediation(t *testing.T) {
+	data := GenerateMediationData(3000, 42)
+
+	res, err := EstimateMediation(data, MediationOptions{Sims: 200, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both bootstrap intervals should cover the true decomposition
+	if res.Direct.CI[0] > data.TrueDirect || res.Direct.CI[1] < data.TrueDirect {
+		t.Errorf("direct CI %v does not cover %.2f", res.Direct.CI, data.TrueDirect)
+	}
+	if res.Indirect.CI[0] > data.TrueIndirect || res.Indirect.CI[1] < data.TrueIndirect {
+		t.Errorf("indirect CI %v does not cover %.2f", res.Indirect.CI, data.TrueIndirect)
+	}
+	if res.ProportionMediated <= 0 || res.ProportionMediated >= 1 {
+		t.Errorf("proportion mediated %.3f out of range", res.ProportionMediated)
+	}
+}
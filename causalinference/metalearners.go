@@ -0,0 +1,273 @@
+package causalinference
+
+import "math/rand"
+
+// MetaLearner estimates conditional average treatment effects by combining
+// ordinary prediction models. Implementations differ in how the base
+// Learner is fit and how its predictions are turned into effects.
+type MetaLearner interface {
+	EstimateCATE(data *CausalData) (CATEResult, error)
+}
+
+// SLearner fits a single outcome model with treatment as an extra feature
+// and takes each unit's effect as the difference between its predictions
+// with treatment set to 1 and to 0
+type SLearner struct {
+	Base Learner // outcome model; defaults to LinearLearner
+}
+
+// EstimateCATE implements MetaLearner
+func (s SLearner) EstimateCATE(data *CausalData) (CATEResult, error) {
+	base := s.Base
+	if base == nil {
+		base = LinearLearner{}
+	}
+
+	rows := covariateRows(data)
+	if err := requireBothArms(data); err != nil {
+		return CATEResult{}, err
+	}
+
+	x := make([][]float64, len(rows))
+	for i, row := range rows {
+		x[i] = withTreatment(row, float64(data.Treatment[i]))
+	}
+	model, err := base.Fit(x, data.Outcome)
+	if err != nil {
+		return CATEResult{}, err
+	}
+
+	cate := make([]float64, len(rows))
+	for i, row := range rows {
+		cate[i] = model.Predict(withTreatment(row, 1)) - model.Predict(withTreatment(row, 0))
+	}
+
+	return newCATEResult(data, cate)
+}
+
+// withTreatment appends a treatment indicator to a covariate row
+func withTreatment(row []float64, t float64) []float64 {
+	return append(append([]float64(nil), row...), t)
+}
+
+// requireBothArms returns ErrEmptyArm unless there are treated and control
+// units, and ErrNonBinaryTreatment if any treatment is not 0 or 1
+func requireBothArms(data *CausalData) error {
+	var treated int
+	for _, t := range data.Treatment {
+		if t != 0 && t != 1 {
+			return ErrNonBinaryTreatment
+		}
+		treated += t
+	}
+	if treated == 0 || treated == len(data.Treatment) {
+		return ErrEmptyArm
+	}
+	return nil
+}
+
+// newCATEResult wraps per-unit effects, their average and their
+// calibration test
+func newCATEResult(data *CausalData, cate []float64) (CATEResult, error) {
+	var sum float64
+	for _, c := range cate {
+		sum += c
+	}
+	test, err := CalibrationTest(data, cate)
+	if err != nil {
+		return CATEResult{}, err
+	}
+	return CATEResult{CATE: cate, ATE: sum / float64(len(cate)), Heterogeneity: test}, nil
+}
+
+// TLearner fits separate outcome models on the treated and control units
+// and takes each unit's effect as the difference of their predictions
+type TLearner struct {
+	Base Learner // outcome model for both arms; defaults to LinearLearner
+}
+
+// EstimateCATE implements MetaLearner
+func (t TLearner) EstimateCATE(data *CausalData) (CATEResult, error) {
+	base := t.Base
+	if base == nil {
+		base = LinearLearner{}
+	}
+	if err := requireBothArms(data); err != nil {
+		return CATEResult{}, err
+	}
+
+	rows := covariateRows(data)
+	mu0, mu1, err := fitArmModels(base, rows, data)
+	if err != nil {
+		return CATEResult{}, err
+	}
+
+	cate := make([]float64, len(rows))
+	for i, row := range rows {
+		cate[i] = mu1.Predict(row) - mu0.Predict(row)
+	}
+
+	return newCATEResult(data, cate)
+}
+
+// fitArmModels fits base separately on control and treated units
+func fitArmModels(base Learner, rows [][]float64, data *CausalData) (Model, Model, error) {
+	var x [2][][]float64
+	var y [2][]float64
+	for i, row := range rows {
+		g := data.Treatment[i]
+		x[g] = append(x[g], row)
+		y[g] = append(y[g], data.Outcome[i])
+	}
+
+	mu0, err := base.Fit(x[0], y[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	mu1, err := base.Fit(x[1], y[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return mu0, mu1, nil
+}
+
+// XLearner imputes individual effects with each arm's outcome model
+// evaluated on the other arm, fits effect models to those imputations, and
+// blends the two effect models with the propensity score:
+// tau(x) = g(x)*tau0(x) + (1-g(x))*tau1(x). It does well when one arm is
+// much larger than the other, as under X-driven treatment.
+type XLearner struct {
+	Base       Learner // outcome and effect models; defaults to LinearLearner
+	Propensity Learner // model for P(T=1|X); defaults to LogisticLearner
+}
+
+// EstimateCATE implements MetaLearner
+func (x XLearner) EstimateCATE(data *CausalData) (CATEResult, error) {
+	base := x.Base
+	if base == nil {
+		base = LinearLearner{}
+	}
+	prop := x.Propensity
+	if prop == nil {
+		prop = LogisticLearner{}
+	}
+	if err := requireBothArms(data); err != nil {
+		return CATEResult{}, err
+	}
+
+	rows := covariateRows(data)
+	mu0, mu1, err := fitArmModels(base, rows, data)
+	if err != nil {
+		return CATEResult{}, err
+	}
+
+	// Imputed effects: treated vs. predicted control, predicted treated vs. control
+	var ex [2][][]float64
+	var ey [2][]float64
+	t := make([]float64, len(rows))
+	for i, row := range rows {
+		g := data.Treatment[i]
+		t[i] = float64(g)
+		ex[g] = append(ex[g], row)
+		if g == 1 {
+			ey[1] = append(ey[1], data.Outcome[i]-mu0.Predict(row))
+		} else {
+			ey[0] = append(ey[0], mu1.Predict(row)-data.Outcome[i])
+		}
+	}
+
+	tau0, err := base.Fit(ex[0], ey[0])
+	if err != nil {
+		return CATEResult{}, err
+	}
+	tau1, err := base.Fit(ex[1], ey[1])
+	if err != nil {
+		return CATEResult{}, err
+	}
+	gModel, err := prop.Fit(rows, t)
+	if err != nil {
+		return CATEResult{}, err
+	}
+
+	cate := make([]float64, len(rows))
+	for i, row := range rows {
+		g := gModel.Predict(row)
+		cate[i] = g*tau0.Predict(row) + (1-g)*tau1.Predict(row)
+	}
+
+	return newCATEResult(data, cate)
+}
+
+// RLearner implements the R-learner of Nie and Wager (2021). Outcome and
+// propensity models are cross-fit to form the Robinson residuals
+// Y - m(X) and T - e(X); the effect model then minimises
+// sum((Y - m(X)) - tau(X)*(T - e(X)))^2, fit as a weighted regression of
+// the pseudo-outcome (Y - m)/(T - e) with weights (T - e)^2.
+type RLearner struct {
+	Base       Learner // effect model; must implement WeightedLearner; defaults to LinearLearner
+	Outcome    Learner // model for E[Y|X]; defaults to LinearLearner
+	Propensity Learner // model for P(T=1|X); defaults to LogisticLearner
+	Folds      int     // cross-fitting folds; 0 means 5
+	Seed       int64   // seed for fold assignment
+}
+
+// EstimateCATE implements MetaLearner
+func (r RLearner) EstimateCATE(data *CausalData) (CATEResult, error) {
+	var base Learner = LinearLearner{}
+	if r.Base != nil {
+		base = r.Base
+	}
+	weighted, ok := base.(WeightedLearner)
+	if !ok {
+		return CATEResult{}, ErrUnweightedLearner
+	}
+	outcome := r.Outcome
+	if outcome == nil {
+		outcome = LinearLearner{}
+	}
+	prop := r.Propensity
+	if prop == nil {
+		prop = LogisticLearner{}
+	}
+	k := r.Folds
+	if k < 2 {
+		k = 5
+	}
+	if err := requireBothArms(data); err != nil {
+		return CATEResult{}, err
+	}
+
+	rows := covariateRows(data)
+	t := make([]float64, len(rows))
+	for i, v := range data.Treatment {
+		t[i] = float64(v)
+	}
+
+	folds := foldAssignments(len(rows), k, rand.New(rand.NewSource(r.Seed)))
+	yRes, err := crossFitResiduals(outcome, rows, data.Outcome, folds, k)
+	if err != nil {
+		return CATEResult{}, err
+	}
+	tRes, err := crossFitResiduals(prop, rows, t, folds, k)
+	if err != nil {
+		return CATEResult{}, err
+	}
+
+	pseudo := make([]float64, len(rows))
+	w := make([]float64, len(rows))
+	for i := range rows {
+		pseudo[i] = yRes[i] / tRes[i]
+		w[i] = tRes[i] * tRes[i]
+	}
+	tau, err := weighted.FitWeighted(rows, pseudo, w)
+	if err != nil {
+		return CATEResult{}, err
+	}
+
+	cate := make([]float64, len(rows))
+	for i, row := range rows {
+		cate[i] = tau.Predict(row)
+	}
+
+	return newCATEResult(data, cate)
+}
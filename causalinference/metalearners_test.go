@@ -0,0 +1,108 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSLearner(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	var learner MetaLearner = SLearner{}
+	res, err := learner.EstimateCATE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.CATE) != len(data.X) {
+		t.Fatalf("got %d CATEs, want %d", len(res.CATE), len(data.X))
+	}
+
+	// A linear base model gives a constant effect equal to the treatment
+	// coefficient of the additive regression
+	x := make([][]float64, len(data.X))
+	for i, v := range data.Column(0) {
+		x[i] = []float64{1, float64(data.Treatment[i]), v}
+	}
+	ols, err := fitOLS(x, data.Outcome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.ATE-ols[1]) > 1e-8 {
+		t.Errorf("S-learner ATE %.6f != OLS coefficient %.6f", res.ATE, ols[1])
+	}
+}
+
+func TestTLearnerHeterogeneity(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	// Effect grows with X: tau(x) = 5 + 2x
+	for i, x := range data.Column(0) {
+		if data.Treatment[i] == 1 {
+			data.Outcome[i] += 2 * x
+		}
+	}
+
+	res, err := TLearner{}.EstimateCATE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range data.Column(0)[:100] {
+		if want := 5 + 2*x; math.Abs(res.CATE[i]-want) > 0.3 {
+			t.Errorf("unit %d: CATE %.3f, want %.3f", i, res.CATE[i], want)
+		}
+	}
+}
+
+func TestXLearner(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+	for i, x := range data.Column(0) {
+		if data.Treatment[i] == 1 {
+			data.Outcome[i] += 2 * x
+		}
+	}
+
+	res, err := XLearner{}.EstimateCATE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Linear effect models recover tau(x) = 5 + 2x from either imputation
+	for i, x := range data.Column(0)[:100] {
+		if want := 5 + 2*x; math.Abs(res.CATE[i]-want) > 0.3 {
+			t.Errorf("unit %d: CATE %.3f, want %.3f", i, res.CATE[i], want)
+		}
+	}
+}
+
+func TestRLearner(t *testing.T) {
+	// Use a logistic propensity so the default propensity model is correct;
+	// the R-learner loss then tolerates the misspecified outcome model
+	rng := rand.New(rand.NewSource(5))
+	data := &CausalData{TrueEffect: 5}
+	for i := 0; i < 4000; i++ {
+		x := rng.NormFloat64()
+		tr := 0
+		if rng.Float64() < sigmoid(x) {
+			tr = 1
+		}
+		data.X = append(data.X, []float64{x})
+		data.Treatment = append(data.Treatment, tr)
+		data.Outcome = append(data.Outcome, x+(5+2*x)*float64(tr)+rng.NormFloat64())
+	}
+
+	res, err := RLearner{Seed: 1}.EstimateCATE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, x := range data.Column(0)[:100] {
+		if want := 5 + 2*x; math.Abs(res.CATE[i]-want) > 0.3 {
+			t.Errorf("unit %d: CATE %.3f, want %.3f", i, res.CATE[i], want)
+		}
+	}
+
+	// The effect model needs weights
+	if _, err := (RLearner{Base: LogisticLearner{}}).EstimateCATE(data); err != ErrUnweightedLearner {
+		t.Errorf("expected ErrUnweightedLearner, got %v", err)
+	}
+}
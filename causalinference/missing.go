@@ -0,0 +1,164 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Missingness selects the mechanism by which values go missing
+type Missingness int
+
+const (
+	// MissingCompletelyAtRandom drops each value with the same probability
+	MissingCompletelyAtRandom Missingness = iota
+	// MissingAtRandom makes missingness depend on always observed values:
+	// covariates go missing more often among treated units and outcomes
+	// more often for units with a high first covariate
+	MissingAtRandom
+	// MissingNotAtRandom makes missingness depend on the value itself, so
+	// high values go missing more often
+	MissingNotAtRandom
+)
+
+// MissingMask records which values of a dataset are missing; true means
+// the value was dropped and is NaN in the data
+type MissingMask struct {
+	X       [][]bool // one row per unit, one entry per covariate
+	Outcome []bool
+}
+
+// MissingOptions configures InjectMissing
+type MissingOptions struct {
+	Mechanism     Missingness // how missingness depends on the data; defaults to MCAR
+	CovariateRate float64     // share of covariate values to drop
+	OutcomeRate   float64     // share of outcomes to drop
+	// Strength is the log odds ratio of missingness per standard
+	// deviation of its driver under MAR and MNAR; 0 means 1
+	Strength float64
+	Seed     int64 // seed of the missingness draws
+}
+
+// InjectMissing returns a copy of data with covariate and outcome values
+// replaced by NaN and the dropped positions recorded in Mask. Under MAR
+// and MNAR each value goes missing with probability
+// logistic(logit(rate) + Strength * z), with z the standardized driver, so
+// the realized share is close to but not exactly the rate. Treatment is
+// never dropped.
+func InjectMissing(data *CausalData, opts MissingOptions) *CausalData {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	strength := opts.Strength
+	if strength == 0 {
+		strength = 1
+	}
+
+	n := len(data.Outcome)
+	out := subsetData(data, allUnits(n))
+	out.Outcome = append([]float64(nil), data.Outcome...)
+	out.Mask = &MissingMask{X: make([][]bool, n), Outcome: make([]bool, n)}
+
+	p := 0
+	if n > 0 {
+		p = len(data.X[0])
+	}
+	columns := make([][]float64, p)
+	for j := range columns {
+		columns[j] = standardize(data.Column(j))
+	}
+	outcome := standardize(data.Outcome)
+
+	// drop reports whether a value with the given driver goes missing
+	drop := func(rate, driver float64) bool {
+		if rate <= 0 || rate >= 1 {
+			return rate >= 1
+		}
+		if opts.Mechanism != MissingCompletelyAtRandom {
+			rate = sigmoid(logit(rate) + strength*driver)
+		}
+		return rng.Float64() < rate
+	}
+
+	for i := range out.X {
+		row := append([]float64(nil), data.X[i]...)
+		out.Mask.X[i] = make([]bool, p)
+		for j := range row {
+			driver := columns[j][i]
+			if opts.Mechanism == MissingAtRandom {
+				driver = 2*float64(data.Treatment[i]) - 1
+			}
+			if drop(opts.CovariateRate, driver) {
+				row[j] = math.NaN()
+				out.Mask.X[i][j] = true
+			}
+		}
+		out.X[i] = row
+
+		driver := outcome[i]
+		if opts.Mechanism == MissingAtRandom && p > 0 {
+			driver = columns[0][i]
+		}
+		if drop(opts.OutcomeRate, driver) {
+			out.Outcome[i] = math.NaN()
+			out.Mask.Outcome[i] = true
+		}
+	}
+	return out
+}
+
+// CompleteCases returns the units with no missing covariate or outcome,
+// the listwise deletion that R's na.omit performs
+func CompleteCases(data *CausalData) *CausalData {
+	var units []int
+	for i, row := range data.X {
+		complete := !math.IsNaN(data.Outcome[i])
+		for _, v := range row {
+			complete = complete && !math.IsNaN(v)
+		}
+		if complete {
+			units = append(units, i)
+		}
+	}
+	return subsetData(data, units)
+}
+
+// ImputeMean returns a copy of data with each missing covariate replaced
+// by the mean of its observed values. Missing outcomes are left as NaN;
+// drop them with CompleteCases or model them separately.
+func ImputeMean(data *CausalData) *CausalData {
+	out := subsetData(data, allUnits(len(data.Outcome)))
+	if len(data.X) == 0 {
+		return out
+	}
+	means := make([]float64, len(data.X[0]))
+	for j := range means {
+		var sum, count float64
+		for _, row := range data.X {
+			if !math.IsNaN(row[j]) {
+				sum += row[j]
+				count++
+			}
+		}
+		means[j] = sum / count
+	}
+	for i, row := range data.X {
+		out.X[i] = append([]float64(nil), row...)
+		for j, v := range row {
+			if math.IsNaN(v) {
+				out.X[i][j] = means[j]
+			}
+		}
+	}
+	return out
+}
+
+// standardize returns (v - mean) / sd, the driver scale used by InjectMissing
+func standardize(v []float64) []float64 {
+	mean, _ := meanAndSE(v)
+	sd := stdDev(v)
+	out := make([]float64, len(v))
+	for i, x := range v {
+		if sd > 0 {
+			out[i] = (x - mean) / sd
+		}
+	}
+	return out
+}
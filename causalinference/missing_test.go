@@ -0,0 +1,63 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInjectMissing(t *testing.T) {
+	data := GenerateCausalData(5000, 17)
+	mcar := InjectMissing(data, MissingOptions{CovariateRate: 0.2, OutcomeRate: 0.1, Seed: 1})
+
+	var missingX, missingY int
+	for i, row := range mcar.X {
+		if math.IsNaN(row[0]) != mcar.Mask.X[i][0] || math.IsNaN(mcar.Outcome[i]) != mcar.Mask.Outcome[i] {
+			t.Fatalf("unit %d: mask disagrees with NaN", i)
+		}
+		if mcar.Mask.X[i][0] {
+			missingX++
+		}
+		if mcar.Mask.Outcome[i] {
+			missingY++
+		}
+	}
+	if share := float64(missingX) / 5000; math.Abs(share-0.2) > 0.02 {
+		t.Errorf("covariate missing share %.3f, want 0.2", share)
+	}
+	if share := float64(missingY) / 5000; math.Abs(share-0.1) > 0.02 {
+		t.Errorf("outcome missing share %.3f, want 0.1", share)
+	}
+	if math.IsNaN(data.X[0][0]) || data.Mask != nil {
+		t.Error("InjectMissing changed its input")
+	}
+
+	// Complete cases under MCAR stay unbiased
+	cc := CompleteCases(mcar)
+	res, err := EstimateRegressionAdjustment(cc, RegressionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("complete-case CI %v does not cover %.1f", res.CI, data.TrueEffect)
+	}
+	if imputed := ImputeMean(mcar); math.IsNaN(imputed.X[firstMissing(mcar)][0]) {
+		t.Error("ImputeMean left a NaN covariate")
+	}
+
+	// MNAR outcomes drop high values, pulling the observed mean down
+	mnar := CompleteCases(InjectMissing(data, MissingOptions{Mechanism: MissingNotAtRandom, OutcomeRate: 0.3, Strength: 2}))
+	full, _ := meanAndSE(data.Outcome)
+	if observed, _ := meanAndSE(mnar.Outcome); observed >= full-0.3 {
+		t.Errorf("MNAR observed mean %.3f not below full mean %.3f", observed, full)
+	}
+}
+
+// firstMissing returns the first unit with a missing covariate
+func firstMissing(data *CausalData) int {
+	for i, row := range data.Mask.X {
+		if row[0] {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,27 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateMSM(t *testing.T) {
+	data := GenerateLongitudinalData(5000, 3, 42)
+
+	res, err := EstimateMSM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.2 {
+		t.Errorf("MSM estimate %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+
+	// Stabilized weights should average close to one
+	var mean float64
+	for _, w := range res.Weights {
+		mean += w / float64(len(res.Weights))
+	}
+	if math.Abs(mean-1) > 0.1 {
+		t.Errorf("mean stabilized weight %.4f, want about 1", mean)
+	}
+}
@@ -0,0 +1,59 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMultinomialPropensityScores(t *testing.T) {
+	data := GenerateMultiArmData(3000, 3, 123)
+	scores, err := MultinomialPropensityScores(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range scores {
+		var sum float64
+		for _, v := range p {
+			sum += v
+		}
+		if len(p) != 3 || math.Abs(sum-1) > 1e-9 {
+			t.Fatalf("unit %d: probabilities %v", i, p)
+		}
+	}
+
+	// Higher X leans toward higher arms
+	lo, hi := 0, 0
+	for i, x := range data.Column(0) {
+		if x < data.X[lo][0] {
+			lo = i
+		}
+		if x > data.X[hi][0] {
+			hi = i
+		}
+	}
+	if scores[hi][2] <= scores[lo][2] {
+		t.Errorf("P(arm 2) %.3f at max X not above %.3f at min X", scores[hi][2], scores[lo][2])
+	}
+
+	// Binary-only methods reject the extra arm
+	if _, err := EstimateWeighted(data, WeightingOptions{}); err != ErrNonBinaryTreatment {
+		t.Errorf("expected ErrNonBinaryTreatment, got %v", err)
+	}
+}
+
+func TestPairwiseEffects(t *testing.T) {
+	data := GenerateMultiArmData(6000, 3, 123)
+	effects, err := EstimatePairwiseEffects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(effects) != 3 {
+		t.Fatalf("got %d pairwise effects, want 3", len(effects))
+	}
+	for _, e := range effects {
+		truth := data.ArmEffects[e.Arm] - data.ArmEffects[e.Reference]
+		if math.Abs(e.Estimate-truth) > 4*e.SE {
+			t.Errorf("arm %d vs %d: estimate %.3f, truth %.1f (SE %.3f)", e.Arm, e.Reference, e.Estimate, truth, e.SE)
+		}
+	}
+}
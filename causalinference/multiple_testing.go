@@ -0,0 +1,151 @@
+package causalinference
+
+import "sort"
+
+// PAdjustMethod selects a multiple-testing correction for a family of
+// p-values
+type PAdjustMethod int
+
+const (
+	// AdjustHolm is Holm's step-down correction, which controls the
+	// family-wise error rate and is uniformly more powerful than Bonferroni;
+	// it is the default, as in R's p.adjust
+	AdjustHolm PAdjustMethod = iota
+	// AdjustBonferroni multiplies every p-value by the number of tests
+	AdjustBonferroni
+	// AdjustBH is the Benjamini-Hochberg step-up correction, which controls
+	// the false discovery rate
+	AdjustBH
+)
+
+// AdjustPValues returns the p-values adjusted for multiple testing by the
+// given method, in the input order and capped at 1. It matches R's
+// p.adjust with method "holm", "bonferroni" or "BH".
+func AdjustPValues(p []float64, method PAdjustMethod) []float64 {
+	m := len(p)
+	adjusted := make([]float64, m)
+	if m == 0 {
+		return adjusted
+	}
+
+	order := make([]int, m)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return p[order[a]] < p[order[b]] })
+
+	switch method {
+	case AdjustBonferroni:
+		for i, v := range p {
+			adjusted[i] = clipProbability(float64(m) * v)
+		}
+	case AdjustBH:
+		// Running minimum of m/rank * p from the largest p-value down
+		running := 1.0
+		for r := m - 1; r >= 0; r-- {
+			i := order[r]
+			if v := float64(m) / float64(r+1) * p[i]; v < running {
+				running = v
+			}
+			adjusted[i] = running
+		}
+	default:
+		// Running maximum of (m - rank + 1) * p from the smallest p-value up
+		var running float64
+		for r, i := range order {
+			if v := float64(m-r) * p[i]; v > running {
+				running = v
+			}
+			adjusted[i] = clipProbability(running)
+		}
+	}
+	return adjusted
+}
+
+// clipProbability caps v at 1
+func clipProbability(v float64) float64 {
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// SubgroupResults holds effect estimates for a family of subgroups with
+// their p-values adjusted for multiple testing
+type SubgroupResults struct {
+	Labels   []string       // name of each subgroup
+	Effects  []EffectResult // effect estimate in each subgroup
+	Method   PAdjustMethod  // correction applied to the p-values
+	Adjusted []float64      // adjusted p-value of each subgroup's effect
+}
+
+// NewSubgroupResults adjusts the p-values of the subgroup effects by the
+// given method. It returns ErrLengthMismatch unless there is one label per
+// effect.
+func NewSubgroupResults(labels []string, effects []EffectResult, method PAdjustMethod) (SubgroupResults, error) {
+	if len(labels) != len(effects) {
+		return SubgroupResults{}, ErrLengthMismatch
+	}
+	p := make([]float64, len(effects))
+	for i, e := range effects {
+		p[i] = e.PValue
+	}
+	return SubgroupResults{
+		Labels:   labels,
+		Effects:  effects,
+		Method:   method,
+		Adjusted: AdjustPValues(p, method),
+	}, nil
+}
+
+// Significant reports which subgroups have an adjusted p-value below alpha
+func (s SubgroupResults) Significant(alpha float64) []bool {
+	out := make([]bool, len(s.Adjusted))
+	for i, p := range s.Adjusted {
+		out[i] = p < alpha
+	}
+	return out
+}
+
+// SubgroupOptions configures EstimateBySubgroup
+type SubgroupOptions struct {
+	// Estimator is run within each subgroup; nil means regression
+	// adjustment with HC2 standard errors
+	Estimator Estimator
+	Adjust    PAdjustMethod // multiple-testing correction; defaults to Holm
+}
+
+// EstimateBySubgroup splits the units by the label group assigns to their
+// covariates, such as "x < 0" and "x >= 0", estimates the effect within
+// each subgroup and adjusts the subgroup p-values for multiple testing.
+// Subgroups are reported in sorted label order. Every subgroup needs
+// treated and control units, or the estimator's error is returned.
+func EstimateBySubgroup(data *CausalData, group func(x []float64) string, opts SubgroupOptions) (SubgroupResults, error) {
+	estimate := opts.Estimator
+	if estimate == nil {
+		estimate = func(d *CausalData) (EffectResult, error) {
+			return EstimateRegressionAdjustment(d, RegressionOptions{Variance: VarianceHC2})
+		}
+	}
+
+	members := map[string][]int{}
+	for i, row := range covariateRows(data) {
+		label := group(row)
+		members[label] = append(members[label], i)
+	}
+	labels := make([]string, 0, len(members))
+	for label := range members {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	effects := make([]EffectResult, len(labels))
+	for k, label := range labels {
+		res, err := estimate(subsetData(data, members[label]))
+		if err != nil {
+			return SubgroupResults{}, err
+		}
+		effects[k] = res
+	}
+	return NewSubgroupResults(labels, effects, opts.Adjust)
+}
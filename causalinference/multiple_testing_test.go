@@ -0,0 +1,83 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAdjustPValues(t *testing.T) {
+	p := []float64{0.01, 0.04, 0.03, 0.005, 0.2}
+
+	// Reference values from R's p.adjust
+	cases := []struct {
+		method PAdjustMethod
+		want   []float64
+	}{
+		{AdjustBonferroni, []float64{0.05, 0.2, 0.15, 0.025, 1}},
+		{AdjustHolm, []float64{0.04, 0.09, 0.09, 0.025, 0.2}},
+		{AdjustBH, []float64{0.025, 0.05, 0.05, 0.025, 0.2}},
+	}
+	for _, c := range cases {
+		got := AdjustPValues(p, c.method)
+		for i := range got {
+			if math.Abs(got[i]-c.want[i]) > 1e-12 {
+				t.Errorf("method %d: adjusted %v, want %v", c.method, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSubgroupResults(t *testing.T) {
+	effects := []EffectResult{
+		newEffectResult("a", 0.5, 0.1, 100),
+		newEffectResult("b", 0.2, 0.1, 100),
+	}
+	res, err := NewSubgroupResults([]string{"young", "old"}, effects, AdjustBonferroni)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// p = 0.0455 for the second subgroup is significant alone but not after
+	// doubling
+	sig := res.Significant(0.05)
+	if !sig[0] || sig[1] || math.Abs(res.Adjusted[1]-2*effects[1].PValue) > 1e-12 {
+		t.Errorf("adjusted %v, significant %v", res.Adjusted, sig)
+	}
+
+	if _, err := NewSubgroupResults([]string{"young"}, effects, AdjustHolm); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch, got %v", err)
+	}
+}
+
+func TestEstimateBySubgroup(t *testing.T) {
+	// The effect is x: negative below zero and positive above
+	data := heterogeneousData(3000, 2)
+	byX := func(x []float64) string {
+		switch {
+		case x[0] < -0.5:
+			return "low"
+		case x[0] < 0.5:
+			return "mid"
+		default:
+			return "high"
+		}
+	}
+
+	res, err := EstimateBySubgroup(data, byX, SubgroupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Labels) != 3 || res.Labels[0] != "high" || res.Labels[2] != "mid" {
+		t.Fatalf("labels %v", res.Labels)
+	}
+	high, low, mid := res.Effects[0], res.Effects[1], res.Effects[2]
+	if high.Estimate < 0.8 || low.Estimate > -0.8 || math.Abs(mid.Estimate) > 0.2 {
+		t.Errorf("subgroup effects %.3f, %.3f, %.3f", low.Estimate, mid.Estimate, high.Estimate)
+	}
+	if sig := res.Significant(0.05); !sig[0] || !sig[1] || sig[2] {
+		t.Errorf("significant %v with adjusted p %v", sig, res.Adjusted)
+	}
+	if high.N+low.N+mid.N != 3000 || high.SE <= 0 {
+		t.Errorf("subgroup sizes %d, %d, %d", low.N, mid.N, high.N)
+	}
+}
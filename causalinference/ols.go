@@ -0,0 +1,235 @@
+package causalinference
+
+import "math"
+
+// olsFit holds a fitted linear regression and what's needed for inference
+type olsFit struct {
+	coef   []float64   // estimated coefficients
+	resid  []float64   // y minus fitted values
+	xtxInv [][]float64 // (X'X)^-1
+	sigma2 float64     // residual variance with n-p degrees of freedom
+}
+
+// fitOLS returns the least squares coefficients of y on the rows of x.
+// Rows of x should already contain an intercept column.
+func fitOLS(x [][]float64, y []float64) ([]float64, error) {
+	return leastSquaresQR(x, y)
+}
+
+// regress fits an OLS model and keeps residuals and (X'X)^-1 for standard errors
+func regress(x [][]float64, y []float64) (*olsFit, error) {
+	coef, err := leastSquaresQR(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	n, p := len(x), len(coef)
+	xtx := make([][]float64, p)
+	for j := range xtx {
+		xtx[j] = make([]float64, p)
+	}
+	resid := make([]float64, n)
+	var rss float64
+	for i, row := range x {
+		resid[i] = y[i] - dot(row, coef)
+		rss += resid[i] * resid[i]
+		for j := 0; j < p; j++ {
+			for k := 0; k < p; k++ {
+				xtx[j][k] += row[j] * row[k]
+			}
+		}
+	}
+
+	xtxInv, err := invertMatrix(xtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &olsFit{
+		coef:   coef,
+		resid:  resid,
+		xtxInv: xtxInv,
+		sigma2: rss / float64(n-p),
+	}, nil
+}
+
+// se returns the classical standard error of coefficient j
+func (f *olsFit) se(j int) float64 {
+	return math.Sqrt(f.sigma2 * f.xtxInv[j][j])
+}
+
+// VarianceType selects the covariance estimator behind regression
+// standard errors
+type VarianceType int
+
+const (
+	// VarianceClassical assumes homoskedastic errors
+	VarianceClassical VarianceType = iota
+	// VarianceHC0 is White's sandwich with squared residuals
+	VarianceHC0
+	// VarianceHC1 scales HC0 by n/(n-p), matching Stata's robust option
+	VarianceHC1
+	// VarianceHC2 divides squared residuals by 1-h, where h is the leverage
+	VarianceHC2
+	// VarianceHC3 divides squared residuals by (1-h)^2, the default of
+	// sandwich::vcovHC
+	VarianceHC3
+	// VarianceCR0 is the cluster-robust sandwich summing score outer
+	// products within clusters, without small-sample correction
+	VarianceCR0
+	// VarianceCR2 is the Bell-McCaffrey cluster-robust sandwich, which
+	// rescales each cluster's residuals by (I - H_gg)^(-1/2), as
+	// clubSandwich does; it reduces to HC2 with singleton clusters
+	VarianceCR2
+)
+
+// covariance returns the coefficient covariance matrix of the requested
+// type. x must be the design matrix the fit was computed from. Cluster
+// types group rows by cluster; a nil cluster puts every row in its own.
+func (f *olsFit) covariance(x [][]float64, cluster []int, kind VarianceType) [][]float64 {
+	p := len(f.coef)
+	if kind == VarianceCR0 || kind == VarianceCR2 {
+		return f.clusterCovariance(x, cluster, kind == VarianceCR2)
+	}
+	if kind == VarianceClassical {
+		cov := make([][]float64, p)
+		for j := range cov {
+			cov[j] = make([]float64, p)
+			for k := range cov[j] {
+				cov[j][k] = f.sigma2 * f.xtxInv[j][k]
+			}
+		}
+		return cov
+	}
+
+	n := float64(len(x))
+	meat := make([][]float64, p)
+	for j := range meat {
+		meat[j] = make([]float64, p)
+	}
+	for i, row := range x {
+		e2 := f.resid[i] * f.resid[i]
+		switch kind {
+		case VarianceHC1:
+			e2 *= n / (n - float64(p))
+		case VarianceHC2, VarianceHC3:
+			h := dot(row, matVec(f.xtxInv, row))
+			if kind == VarianceHC2 {
+				e2 /= 1 - h
+			} else {
+				e2 /= (1 - h) * (1 - h)
+			}
+		}
+		for j := 0; j < p; j++ {
+			for k := 0; k < p; k++ {
+				meat[j][k] += e2 * row[j] * row[k]
+			}
+		}
+	}
+	return matMul(matMul(f.xtxInv, meat), f.xtxInv)
+}
+
+// clusterCovariance is the CR0 or, with adjust set, CR2 cluster-robust
+// covariance matrix
+func (f *olsFit) clusterCovariance(x [][]float64, cluster []int, adjust bool) [][]float64 {
+	p := len(f.coef)
+	meat := make([][]float64, p)
+	for j := range meat {
+		meat[j] = make([]float64, p)
+	}
+	for _, rows := range clusterRows(len(x), cluster) {
+		e := make([]float64, len(rows))
+		for a, i := range rows {
+			e[a] = f.resid[i]
+		}
+		if adjust {
+			e = f.cr2Adjust(x, rows, e)
+		}
+
+		score := make([]float64, p)
+		for a, i := range rows {
+			for j := range score {
+				score[j] += x[i][j] * e[a]
+			}
+		}
+		for j := 0; j < p; j++ {
+			for k := 0; k < p; k++ {
+				meat[j][k] += score[j] * score[k]
+			}
+		}
+	}
+	return matMul(matMul(f.xtxInv, meat), f.xtxInv)
+}
+
+// cr2Adjust returns (I - H_gg)^(-1/2) e for the rows of one cluster, where
+// H_gg = X_g (X'X)^-1 X_g'. With Z = X_g L and L L' = (X'X)^-1, H_gg = Z Z'
+// has rank at most p, so the eigenvectors of Z'Z give the adjustment
+// without forming the cluster-sized matrix.
+func (f *olsFit) cr2Adjust(x [][]float64, rows []int, e []float64) []float64 {
+	l, err := cholesky(f.xtxInv)
+	if err != nil {
+		return e
+	}
+	p := len(f.coef)
+	z := make([][]float64, len(rows))
+	for a, i := range rows {
+		z[a] = make([]float64, p)
+		for k := 0; k < p; k++ {
+			for j := k; j < p; j++ {
+				z[a][k] += x[i][j] * l[j][k]
+			}
+		}
+	}
+
+	values, vectors := symmetricEigen(matMul(transpose(z), z))
+	out := append([]float64(nil), e...)
+	for k, s2 := range values {
+		if s2 <= jacobiTolerance {
+			continue
+		}
+		// Unit eigenvector u = Z v / s of H_gg with eigenvalue s^2
+		u := make([]float64, len(rows))
+		var ue float64
+		for a := range rows {
+			for j := 0; j < p; j++ {
+				u[a] += z[a][j] * vectors[j][k]
+			}
+			u[a] /= math.Sqrt(s2)
+			ue += u[a] * e[a]
+		}
+		// Eigenvalues of 1 (a cluster that alone determines a coefficient)
+		// have no inverse; drop that direction as a generalized inverse would
+		scale := -1.0
+		if s2 < 1-jacobiTolerance {
+			scale = 1/math.Sqrt(1-s2) - 1
+		}
+		for a := range out {
+			out[a] += scale * u[a] * ue
+		}
+	}
+	return out
+}
+
+// clusterRows groups row indices 0..n-1 by cluster, in order of each
+// cluster's first row. A nil cluster gives every row its own group.
+func clusterRows(n int, cluster []int) [][]int {
+	if cluster == nil {
+		groups := make([][]int, n)
+		for i := range groups {
+			groups[i] = []int{i}
+		}
+		return groups
+	}
+	index := make(map[int]int)
+	var groups [][]int
+	for i, c := range cluster {
+		g, ok := index[c]
+		if !ok {
+			g = len(groups)
+			index[c] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
@@ -0,0 +1,114 @@
+package causalinference
+
+import "math"
+
+// Settings for the quasi-Newton minimiser
+const (
+	bfgsMaxIter   = 500
+	bfgsTolerance = 1e-9
+)
+
+// minimizeBFGS minimises a smooth function with BFGS, using central finite
+// differences for the gradient and a backtracking Armijo line search
+func minimizeBFGS(f func([]float64) float64, x0 []float64) ([]float64, error) {
+	n := len(x0)
+	x := append([]float64(nil), x0...)
+	fx := f(x)
+	g := numericGradient(f, x)
+
+	// Inverse Hessian approximation, starting from the identity
+	h := make([][]float64, n)
+	for i := range h {
+		h[i] = make([]float64, n)
+		h[i][i] = 1
+	}
+
+	for iter := 0; iter < bfgsMaxIter; iter++ {
+		if math.Sqrt(dot(g, g)) < bfgsTolerance {
+			return x, nil
+		}
+
+		dir := make([]float64, n)
+		for i := range dir {
+			dir[i] = -dot(h[i], g)
+		}
+		slope := dot(g, dir)
+		if slope >= 0 {
+			// Not a descent direction; restart from steepest descent
+			for i := range h {
+				for j := range h[i] {
+					h[i][j] = 0
+				}
+				h[i][i] = 1
+				dir[i] = -g[i]
+			}
+			slope = -dot(g, g)
+		}
+
+		step := 1.0
+		next := make([]float64, n)
+		var fNext float64
+		for ; step > 1e-12; step /= 2 {
+			for i := range next {
+				next[i] = x[i] + step*dir[i]
+			}
+			fNext = f(next)
+			if fNext <= fx+1e-4*step*slope {
+				break
+			}
+		}
+		if step <= 1e-12 {
+			// No further progress is possible at this precision
+			return x, nil
+		}
+
+		gNext := numericGradient(f, next)
+		s := make([]float64, n)
+		y := make([]float64, n)
+		for i := range s {
+			s[i] = next[i] - x[i]
+			y[i] = gNext[i] - g[i]
+		}
+		if sy := dot(s, y); sy > 1e-12 {
+			bfgsUpdate(h, s, y, sy)
+		}
+
+		if math.Abs(fx-fNext) < bfgsTolerance*(1+math.Abs(fx)) {
+			return next, nil
+		}
+		x, fx, g = next, fNext, gNext
+	}
+
+	return x, ErrNoConvergence
+}
+
+// bfgsUpdate applies the BFGS update to the inverse Hessian approximation h
+func bfgsUpdate(h [][]float64, s, y []float64, sy float64) {
+	n := len(s)
+	hy := make([]float64, n)
+	for i := range hy {
+		hy[i] = dot(h[i], y)
+	}
+	yhy := dot(y, hy)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			h[i][j] += (sy+yhy)*s[i]*s[j]/(sy*sy) - (hy[i]*s[j]+s[i]*hy[j])/sy
+		}
+	}
+}
+
+// numericGradient approximates the gradient of f at x by central differences
+func numericGradient(f func([]float64) float64, x []float64) []float64 {
+	g := make([]float64, len(x))
+	probe := append([]float64(nil), x...)
+	for i := range x {
+		h := 1e-6 * math.Max(1, math.Abs(x[i]))
+		probe[i] = x[i] + h
+		up := f(probe)
+		probe[i] = x[i] - h
+		down := f(probe)
+		probe[i] = x[i]
+		g[i] = (up - down) / (2 * h)
+	}
+	return g
+}
@@ -0,0 +1,86 @@
+package causalinference
+
+import "math"
+
+// overlapWeight returns a unit's overlap weight: the probability of being
+// assigned to the other arm
+func overlapWeight(t int, p float64) float64 {
+	if t == 1 {
+		return 1 - p
+	}
+	return p
+}
+
+// overlapSandwichSE returns the M-estimation standard error of the overlap
+// weighted difference when the scores come from the logistic model. The
+// parameters are the two weighted arm means and the logistic coefficients,
+// with estimating equations
+//
+//	T(1-e)(Y-mu1) = 0,  (1-T)e(Y-mu0) = 0,  (T-e)x = 0
+//
+// and the variance is A^-1 B A^-T / n as in PSweight.
+func overlapSandwichSE(data *CausalData, scores []float64) (float64, error) {
+	x := withIntercept(covariateRows(data))
+	k := len(x[0]) + 2
+
+	var sum, total [2]float64
+	for i, e := range scores {
+		g := data.Treatment[i]
+		w := overlapWeight(g, e)
+		sum[g] += w * data.Outcome[i]
+		total[g] += w
+	}
+	mu := [2]float64{sum[0] / total[0], sum[1] / total[1]}
+
+	// a is the negative mean Jacobian, b the mean outer product of the
+	// estimating functions; parameter order is (mu1, mu0, beta)
+	a := make([][]float64, k)
+	b := make([][]float64, k)
+	for j := range a {
+		a[j] = make([]float64, k)
+		b[j] = make([]float64, k)
+	}
+	psi := make([]float64, k)
+	for i, row := range x {
+		e := scores[i]
+		t := float64(data.Treatment[i])
+		y := data.Outcome[i]
+		v := e * (1 - e)
+
+		psi[0] = t * (1 - e) * (y - mu[1])
+		psi[1] = (1 - t) * e * (y - mu[0])
+		a[0][0] += t * (1 - e)
+		a[1][1] += (1 - t) * e
+		for j, xj := range row {
+			psi[2+j] = (t - e) * xj
+			a[0][2+j] += t * v * (y - mu[1]) * xj
+			a[1][2+j] -= (1 - t) * v * (y - mu[0]) * xj
+			for l, xl := range row {
+				a[2+j][2+l] += v * xj * xl
+			}
+		}
+
+		for r := 0; r < k; r++ {
+			for c := 0; c < k; c++ {
+				b[r][c] += psi[r] * psi[c]
+			}
+		}
+	}
+
+	aInv, err := invertMatrix(a)
+	if err != nil {
+		return 0, err
+	}
+
+	// Contrast c = (1, -1, 0, ...), so the variance is (c'A^-1) B (c'A^-1)'
+	// with the 1/n factors cancelling between A and B
+	g := make([]float64, k)
+	for j := range g {
+		g[j] = aInv[0][j] - aInv[1][j]
+	}
+	var variance float64
+	for r := 0; r < k; r++ {
+		variance += g[r] * dot(b[r], g)
+	}
+	return math.Sqrt(variance), nil
+}
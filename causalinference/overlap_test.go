@@ -0,0 +1,39 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOverlapWeights(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	res, err := EstimateWeighted(data, WeightingOptions{Scheme: WeightOverlap})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Method != "OverlapWeights" || res.SE <= 0 {
+		t.Fatalf("invalid result %+v", res.EffectResult)
+	}
+	// The effect is constant, so the overlap population shares it
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.15 {
+		t.Errorf("ATO %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+
+	// Logistic overlap weights balance the covariate means exactly
+	var sum, total [2]float64
+	for i, w := range res.Weights {
+		g := data.Treatment[i]
+		sum[g] += w * data.X[i][0]
+		total[g] += w
+	}
+	if gap := math.Abs(sum[1]/total[1] - sum[0]/total[0]); gap > 1e-6 {
+		t.Errorf("weighted covariate gap %.2e, want 0", gap)
+	}
+
+	// Overlap weights fix their own target population
+	_, err = EstimateWeighted(data, WeightingOptions{Scheme: WeightOverlap, Estimand: EstimandATT})
+	if err != ErrUnsupportedEstimand {
+		t.Errorf("expected ErrUnsupportedEstimand, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package causalinference
+
+import "math"
+
+// Settings for alternating-projection demeaning on unbalanced panels
+const (
+	withinMaxIter   = 1000
+	withinTolerance = 1e-10
+)
+
+// PanelData holds long-format panel observations, one entry per unit-period
+type PanelData struct {
+	Unit       []int     // unit identifier of each observation
+	Time       []int     // period identifier of each observation
+	Treatment  []float64 // treatment status or dose of each observation
+	Outcome    []float64 // observed outcome
+	TrueEffect float64   // for testing
+	// EventEffects holds the true effect at each event time 0, 1, ... for
+	// staggered adoption data; for testing
+	EventEffects []float64
+}
+
+// EstimateFixedEffects estimates the treatment effect with unit and time
+// fixed effects by the within transformation: Outcome and Treatment are
+// demeaned by unit and by period, then regressed on each other. Unbalanced
+// panels are handled by alternating projections. Standard errors are
+// classical with degrees of freedom reduced for the absorbed effects, as in
+// plm's "twoways" within model.
+func EstimateFixedEffects(data *PanelData) (EffectResult, error) {
+	coef, se, err := withinRegression(data.Unit, data.Time, data.Outcome, [][]float64{data.Treatment})
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	return newEffectResult("TwoWayFE", coef[0], se[0], len(data.Outcome)), nil
+}
+
+// withinRegression absorbs unit and time effects from y and each regressor
+// column, then fits OLS without an intercept on the demeaned data. It
+// returns the coefficients and their classical standard errors.
+func withinRegression(unit, time []int, y []float64, regressors [][]float64) ([]float64, []float64, error) {
+	coef, cov, err := withinFit(unit, time, y, regressors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	se := make([]float64, len(coef))
+	for j := range se {
+		se[j] = math.Sqrt(cov[j][j])
+	}
+	return coef, se, nil
+}
+
+// withinFit is withinRegression returning the full classical covariance
+// matrix of the coefficients
+func withinFit(unit, time []int, y []float64, regressors [][]float64) ([]float64, [][]float64, error) {
+	n := len(y)
+	ty := demeanTwoWay(unit, time, y)
+
+	x := make([][]float64, n)
+	for i := range x {
+		x[i] = make([]float64, len(regressors))
+	}
+	for j, col := range regressors {
+		for i, v := range demeanTwoWay(unit, time, col) {
+			x[i][j] = v
+		}
+	}
+
+	fit, err := regress(x, ty)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// regress assumed n-p residual degrees of freedom; also remove the
+	// absorbed unit and period effects (minus the one shared constant)
+	dof := n - len(fit.coef) - countDistinct(unit) - countDistinct(time) + 1
+	if dof <= 0 {
+		return nil, nil, ErrNoObservations
+	}
+	sigma2 := fit.sigma2 * float64(n-len(fit.coef)) / float64(dof)
+
+	cov := make([][]float64, len(fit.coef))
+	for j := range cov {
+		cov[j] = make([]float64, len(fit.coef))
+		for k := range cov[j] {
+			cov[j][k] = sigma2 * fit.xtxInv[j][k]
+		}
+	}
+	return fit.coef, cov, nil
+}
+
+// demeanTwoWay removes unit and time means from v, iterating until the
+// result is orthogonal to both sets of dummies
+func demeanTwoWay(unit, time []int, v []float64) []float64 {
+	out := append([]float64(nil), v...)
+	for iter := 0; iter < withinMaxIter; iter++ {
+		change := demeanBy(unit, out)
+		change = math.Max(change, demeanBy(time, out))
+		if change < withinTolerance {
+			break
+		}
+	}
+	return out
+}
+
+// demeanBy subtracts group means in place and returns the largest mean removed
+func demeanBy(group []int, v []float64) float64 {
+	sums := make(map[int]float64)
+	counts := make(map[int]float64)
+	for i, g := range group {
+		sums[g] += v[i]
+		counts[g]++
+	}
+
+	var change float64
+	for g := range sums {
+		sums[g] /= counts[g]
+		change = math.Max(change, math.Abs(sums[g]))
+	}
+	for i, g := range group {
+		v[i] -= sums[g]
+	}
+	return change
+}
+
+// countDistinct returns the number of distinct values in ids
+func countDistinct(ids []int) int {
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,182 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Adoption selects how treatment switches on in GeneratePanelData
+type Adoption int
+
+const (
+	// AdoptionStaggered treats each ever-treated unit from the start of
+	// one of Cohorts adoption periods onward; treatment is absorbing
+	AdoptionStaggered Adoption = iota
+	// AdoptionSimultaneous treats every ever-treated unit from the middle
+	// period onward, the classic two-group design
+	AdoptionSimultaneous
+	// AdoptionSwitching treats each ever-treated unit in each period
+	// independently with probability one half, so treatment turns on and off
+	AdoptionSwitching
+)
+
+// PanelConfig holds the parameters of GeneratePanelData. Unit u in period t
+// has outcome alpha_u + gamma_t + effect * D_ut + e_ut, where alpha_u has
+// standard deviation UnitSD and is shifted up by Selection for ever-treated
+// units, gamma_t = TimeTrend * t plus TimeSD * N(0, 1) shocks, and e_ut is a
+// stationary AR(1) process with coefficient AR and standard deviation
+// NoiseSD.
+type PanelConfig struct {
+	Effect       float64  // effect in the period of adoption
+	EffectGrowth float64  // change of the effect per period since adoption; 0 keeps it constant
+	UnitSD       float64  // standard deviation of the unit effects
+	Selection    float64  // shift of the unit effects of ever-treated units, so adopters differ in levels
+	TimeTrend    float64  // common linear trend per period
+	TimeSD       float64  // standard deviation of common period shocks
+	AR           float64  // first-order autocorrelation of the errors, in (-1, 1)
+	NoiseSD      float64  // standard deviation of the errors
+	Adoption     Adoption // how treatment switches on
+	TreatedShare float64  // share of units that are ever treated
+	Cohorts      int      // adoption cohorts for staggered adoption; below 1 means 1
+}
+
+// DefaultPanelConfig returns the design GeneratePanelData uses without
+// options: a staggered design with three cohorts among
+// three quarters of the units, a constant effect of 2, unit effects that
+// are higher for adopters, a common trend and independent errors
+func DefaultPanelConfig() PanelConfig {
+	return PanelConfig{
+		Effect:       2,
+		UnitSD:       1,
+		Selection:    1,
+		TimeTrend:    0.3,
+		NoiseSD:      1,
+		TreatedShare: 0.75,
+		Cohorts:      3,
+	}
+}
+
+// PanelOption changes one parameter of the panel data generating process
+type PanelOption func(*PanelConfig)
+
+// WithPanelEffect sets the effect in the period of adoption and its change
+// per period since adoption
+func WithPanelEffect(effect, growth float64) PanelOption {
+	return func(c *PanelConfig) {
+		c.Effect = effect
+		c.EffectGrowth = growth
+	}
+}
+
+// WithUnitEffects sets the standard deviation of the unit effects and
+// their shift for ever-treated units
+func WithUnitEffects(sd, selection float64) PanelOption {
+	return func(c *PanelConfig) {
+		c.UnitSD = sd
+		c.Selection = selection
+	}
+}
+
+// WithTimeEffects sets the common linear trend per period and the
+// standard deviation of common period shocks
+func WithTimeEffects(trend, sd float64) PanelOption {
+	return func(c *PanelConfig) {
+		c.TimeTrend = trend
+		c.TimeSD = sd
+	}
+}
+
+// WithPanelErrors sets the standard deviation of the errors and their
+// first-order autocorrelation, in (-1, 1)
+func WithPanelErrors(sd, ar float64) PanelOption {
+	return func(c *PanelConfig) {
+		c.NoiseSD = sd
+		c.AR = ar
+	}
+}
+
+// WithAdoption sets how treatment switches on and the share of units that
+// are ever treated
+func WithAdoption(kind Adoption, treatedShare float64) PanelOption {
+	return func(c *PanelConfig) {
+		c.Adoption = kind
+		c.TreatedShare = treatedShare
+	}
+}
+
+// WithCohorts sets the number of adoption cohorts of staggered adoption
+func WithCohorts(k int) PanelOption {
+	return func(c *PanelConfig) { c.Cohorts = k }
+}
+
+// GeneratePanelData creates a balanced panel of nUnits observed over
+// nPeriods from DefaultPanelConfig changed by the given options. Staggered
+// cohorts adopt in periods spread evenly over 1 .. nPeriods-1, so each has
+// a pre-period. TrueEffect is the effect at adoption and EventEffects the
+// effect at each event time; with switching adoption the effect stays
+// constant.
+func GeneratePanelData(nUnits, nPeriods int, seed int64, opts ...PanelOption) *PanelData {
+	cfg := DefaultPanelConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	data := &PanelData{TrueEffect: cfg.Effect, EventEffects: make([]float64, nPeriods)}
+	for e := range data.EventEffects {
+		data.EventEffects[e] = cfg.Effect
+		if cfg.Adoption != AdoptionSwitching {
+			data.EventEffects[e] += cfg.EffectGrowth * float64(e)
+		}
+	}
+
+	gamma := make([]float64, nPeriods)
+	for t := range gamma {
+		gamma[t] = cfg.TimeTrend*float64(t) + cfg.TimeSD*rng.NormFloat64()
+	}
+	cohorts := cfg.Cohorts
+	if cohorts < 1 {
+		cohorts = 1
+	}
+	innovation := cfg.NoiseSD * math.Sqrt(1-cfg.AR*cfg.AR)
+
+	for u := 0; u < nUnits; u++ {
+		// adopt is -1 for never-treated units
+		adopt := -1
+		alpha := cfg.UnitSD * rng.NormFloat64()
+		if rng.Float64() < cfg.TreatedShare {
+			alpha += cfg.Selection
+			switch cfg.Adoption {
+			case AdoptionStaggered:
+				adopt = 1 + rng.Intn(cohorts)*(nPeriods-1)/cohorts
+			default:
+				adopt = nPeriods / 2
+			}
+		}
+
+		// Start the errors at their stationary distribution
+		e := cfg.NoiseSD * rng.NormFloat64()
+		for t := 0; t < nPeriods; t++ {
+			if t > 0 {
+				e = cfg.AR*e + innovation*rng.NormFloat64()
+			}
+			var tr, effect float64
+			switch {
+			case adopt < 0:
+			case cfg.Adoption == AdoptionSwitching:
+				if rng.Float64() < 0.5 {
+					tr, effect = 1, cfg.Effect
+				}
+			case t >= adopt:
+				tr, effect = 1, data.EventEffects[t-adopt]
+			}
+			data.Unit = append(data.Unit, u)
+			data.Time = append(data.Time, t)
+			data.Treatment = append(data.Treatment, tr)
+			data.Outcome = append(data.Outcome, alpha+gamma[t]+effect+e)
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,61 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeneratePanelData(t *testing.T) {
+	data := GeneratePanelData(400, 8, 3)
+	if len(data.Outcome) != 400*8 {
+		t.Fatalf("%d observations, want %d", len(data.Outcome), 400*8)
+	}
+
+	// A constant effect keeps two-way fixed effects unbiased under
+	// staggered adoption and selection on levels
+	res, err := EstimateFixedEffects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v does not cover %.1f", res.CI, data.TrueEffect)
+	}
+
+	// Dynamic effects are recovered by the event study
+	dynamic := GeneratePanelData(600, 8, 3, WithPanelEffect(2, 0.5))
+	study, err := EstimateEventStudy(dynamic, EventStudyOptions{Leads: 1, Lags: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range study.Effects {
+		var truth float64
+		if e.EventTime >= 0 {
+			truth = dynamic.EventEffects[e.EventTime]
+		}
+		if math.Abs(e.Estimate-truth) > 4*e.SE {
+			t.Errorf("event time %d: %.3f, want %.2f", e.EventTime, e.Estimate, truth)
+		}
+	}
+}
+
+func TestPanelSerialCorrelation(t *testing.T) {
+	// Outcomes are the errors alone, so their lag-one correlation is AR
+	data := GeneratePanelData(2000, 10, 5,
+		WithUnitEffects(0, 0), WithTimeEffects(0, 0), WithPanelErrors(2, 0.6), WithAdoption(AdoptionStaggered, 0))
+
+	var xy, xx, yy float64
+	for i := 1; i < len(data.Outcome); i++ {
+		if data.Unit[i] != data.Unit[i-1] {
+			continue
+		}
+		xy += data.Outcome[i] * data.Outcome[i-1]
+		xx += data.Outcome[i-1] * data.Outcome[i-1]
+		yy += data.Outcome[i] * data.Outcome[i]
+	}
+	if rho := xy / math.Sqrt(xx*yy); math.Abs(rho-0.6) > 0.03 {
+		t.Errorf("lag-one correlation %.3f, want 0.6", rho)
+	}
+	if sd := stdDev(data.Outcome); math.Abs(sd-2) > 0.1 {
+		t.Errorf("error SD %.3f, want 2", sd)
+	}
+}
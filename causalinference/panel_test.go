@@ -0,0 +1,53 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// buildPanel creates a panel where treatment is correlated with unit effects
+func buildPanel(nUnits, nPeriods int, seed int64) *PanelData {
+	rng := rand.New(rand.NewSource(seed))
+	data := &PanelData{TrueEffect: 2}
+
+	for u := 0; u < nUnits; u++ {
+		alpha := rng.NormFloat64()
+		for t := 0; t < nPeriods; t++ {
+			// Units with high alpha are treated more often
+			tr := 0.0
+			if rng.Float64() < sigmoid(2*alpha) {
+				tr = 1
+			}
+			data.Unit = append(data.Unit, u)
+			data.Time = append(data.Time, t)
+			data.Treatment = append(data.Treatment, tr)
+			data.Outcome = append(data.Outcome, 3*alpha+0.5*float64(t)+data.TrueEffect*tr+rng.NormFloat64())
+		}
+	}
+	return data
+}
+
+func TestFixedEffects(t *testing.T) {
+	data := buildPanel(300, 6, 42)
+
+	res, err := EstimateFixedEffects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v does not cover %.1f", res.CI, data.TrueEffect)
+	}
+
+	// Dropping observations makes the panel unbalanced but must still work
+	keep := func(s []int) []int { return s[:len(s)-7] }
+	data.Unit, data.Time = keep(data.Unit), keep(data.Time)
+	data.Treatment, data.Outcome = data.Treatment[:len(data.Unit)], data.Outcome[:len(data.Unit)]
+	res, err = EstimateFixedEffects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.2 {
+		t.Errorf("unbalanced estimate %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+}
@@ -0,0 +1,69 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+)
+
+// PermutationOptions configures PermutationTest
+type PermutationOptions struct {
+	Seed int64 // seed for the re-randomizations
+}
+
+// PermutationResult holds a randomization test of no effect
+type PermutationResult struct {
+	Observed EffectResult // estimate on the actual assignment
+	Null     []float64    // estimate under each re-randomization, in draw order
+	// PValue is the two-sided p-value (1 + #{|null| >= |observed|}) / (1 + perms),
+	// which counts the observed assignment as one of the draws so it is
+	// never zero
+	PValue float64
+}
+
+// PermutationTest performs randomization inference: it re-draws the
+// treatment assignment perms times (0 means 1000) by permuting the
+// observed treatment vector, which keeps the arm sizes as in a completely
+// randomized experiment, and recomputes the estimate under each. Under
+// the sharp null of no effect for any unit the outcomes do not change, so
+// the estimates form the exact null distribution of the statistic.
+// Re-randomizations run in parallel, so the estimator must be safe for
+// concurrent use; results do not depend on scheduling.
+func PermutationTest(data *CausalData, estimate Estimator, perms int, opts PermutationOptions) (PermutationResult, error) {
+	if perms < 1 {
+		perms = 1000
+	}
+
+	observed, err := estimate(data)
+	if err != nil {
+		return PermutationResult{}, err
+	}
+
+	n := len(data.Outcome)
+	null, errs := parallelEstimates(estimate, perms, func(r int) *CausalData {
+		// Seed per permutation so results don't depend on scheduling
+		rng := rand.New(rand.NewSource(opts.Seed + int64(r)))
+		perm := subsetData(data, allUnits(n))
+		for i, j := range rng.Perm(n) {
+			perm.Treatment[i] = data.Treatment[j]
+		}
+		perm.TrueEffect = 0
+		return perm
+	})
+	for _, err := range errs {
+		if err != nil {
+			return PermutationResult{}, err
+		}
+	}
+
+	extreme := 1
+	for _, v := range null {
+		if math.Abs(v) >= math.Abs(observed.Estimate) {
+			extreme++
+		}
+	}
+	return PermutationResult{
+		Observed: observed,
+		Null:     null,
+		PValue:   float64(extreme) / float64(perms+1),
+	}, nil
+}
@@ -0,0 +1,50 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPermutationTest(t *testing.T) {
+	// Randomized experiment with a small effect
+	rng := rand.New(rand.NewSource(11))
+	n := 200
+	data := &CausalData{X: make([][]float64, n), Treatment: make([]int, n), Outcome: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		data.X[i] = []float64{rng.NormFloat64()}
+		data.Treatment[i] = i % 2
+		data.Outcome[i] = 0.5*float64(data.Treatment[i]) + rng.NormFloat64()
+	}
+
+	res, err := PermutationTest(data, EstimateCausalEffect, 999, PermutationOptions{Seed: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Null) != 999 {
+		t.Fatalf("got %d null draws", len(res.Null))
+	}
+	// The permutation p-value should agree roughly with the normal test
+	z := res.Observed.Estimate / res.Observed.SE
+	normal := 2 * (1 - normalCDF(math.Abs(z)))
+	if res.PValue > 0.01 || normal > 0.01 {
+		t.Errorf("p-values: permutation %.4f, normal %.4f", res.PValue, normal)
+	}
+
+	// Under the sharp null the p-value is roughly uniform; check the
+	// rejection rate over several datasets stays near 5%
+	var rejections int
+	for s := int64(0); s < 40; s++ {
+		rng := rand.New(rand.NewSource(100 + s))
+		for i := range data.Outcome {
+			data.Outcome[i] = rng.NormFloat64()
+		}
+		res, _ := PermutationTest(data, EstimateCausalEffect, 199, PermutationOptions{Seed: s})
+		if res.PValue <= 0.05 {
+			rejections++
+		}
+	}
+	if rejections > 6 {
+		t.Errorf("%d of 40 null datasets rejected at 5%%", rejections)
+	}
+}
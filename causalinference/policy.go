@@ -0,0 +1,212 @@
+package causalinference
+
+import "sort"
+
+// PolicyTree is a shallow decision tree that maps covariates to a
+// treatment decision. Internal nodes send rows with
+// row[Feature] <= Threshold to Left; leaves have nil children.
+type PolicyTree struct {
+	Feature     int
+	Threshold   float64
+	Left, Right *PolicyTree
+	Treat       bool // decision at a leaf
+}
+
+// Assign returns 1 if the tree treats a unit with the given covariates
+func (t *PolicyTree) Assign(row []float64) int {
+	for t.Left != nil {
+		if row[t.Feature] <= t.Threshold {
+			t = t.Left
+		} else {
+			t = t.Right
+		}
+	}
+	if t.Treat {
+		return 1
+	}
+	return 0
+}
+
+// PolicyOptions configures policy learning
+type PolicyOptions struct {
+	Depth int // tree depth; 0 means 2. Search time grows as n^Depth
+}
+
+// PolicyResult holds a learned treatment rule and its estimated value
+type PolicyResult struct {
+	Tree   *PolicyTree
+	Assign []int        // the rule's decision for each unit
+	Value  EffectResult // doubly robust estimate of the mean outcome under the rule
+	// Gain is the estimated improvement in mean outcome over treating
+	// nobody, which is the average effect among the units the rule treats
+	// times their share
+	Gain EffectResult
+}
+
+// LearnPolicy finds the depth-limited tree that maximises the estimated
+// mean outcome, as policytree does: each unit gets doubly robust scores
+// for its potential outcomes (see EstimateAIPW), and an exhaustive search
+// over splits on every covariate picks the tree whose decisions collect
+// the largest total score. The reported value is evaluated on the same
+// data the tree was chosen on, so it is optimistic; use EvaluatePolicy on
+// held-out data for an honest estimate.
+func LearnPolicy(data *CausalData, opts PolicyOptions) (PolicyResult, error) {
+	depth := opts.Depth
+	if depth < 1 {
+		depth = 2
+	}
+	if err := requireBothArms(data); err != nil {
+		return PolicyResult{}, err
+	}
+	g1, g0, err := aipwScores(data)
+	if err != nil {
+		return PolicyResult{}, err
+	}
+
+	rows := covariateRows(data)
+	search := &policySearch{rows: rows, gain: make([]float64, len(rows))}
+	for i := range rows {
+		search.gain[i] = g1[i] - g0[i]
+	}
+	// Presort units on each feature once; subsets keep this order
+	for f := range rows[0] {
+		order := allUnits(len(rows))
+		sort.SliceStable(order, func(a, b int) bool { return rows[order[a]][f] < rows[order[b]][f] })
+		search.order = append(search.order, order)
+	}
+
+	in := make([]bool, len(rows))
+	for i := range in {
+		in[i] = true
+	}
+	_, tree := search.best(in, depth)
+
+	assign := make([]int, len(rows))
+	for i, row := range rows {
+		assign[i] = tree.Assign(row)
+	}
+	value, gain := policyValue(g1, g0, assign)
+	return PolicyResult{Tree: tree, Assign: assign, Value: value, Gain: gain}, nil
+}
+
+// EvaluatePolicy estimates the mean outcome if units were treated
+// according to assign (0 or 1 per unit), by averaging each unit's doubly
+// robust score for its assigned arm. The standard error treats the rule
+// as fixed.
+func EvaluatePolicy(data *CausalData, assign []int) (EffectResult, error) {
+	if len(assign) != len(data.Treatment) {
+		return EffectResult{}, ErrLengthMismatch
+	}
+	if err := requireBothArms(data); err != nil {
+		return EffectResult{}, err
+	}
+	g1, g0, err := aipwScores(data)
+	if err != nil {
+		return EffectResult{}, err
+	}
+	value, _ := policyValue(g1, g0, assign)
+	return value, nil
+}
+
+// policyValue averages the scores of the assigned arms, and the score
+// differences among treated units for the gain over treating nobody
+func policyValue(g1, g0 []float64, assign []int) (EffectResult, EffectResult) {
+	value := make([]float64, len(assign))
+	gain := make([]float64, len(assign))
+	for i, a := range assign {
+		value[i] = g0[i]
+		if a == 1 {
+			value[i] = g1[i]
+			gain[i] = g1[i] - g0[i]
+		}
+	}
+	v, vse := meanAndSE(value)
+	g, gse := meanAndSE(gain)
+	return newEffectResult("DRPolicyValue", v, vse, len(assign)), newEffectResult("DRPolicyGain", g, gse, len(assign))
+}
+
+// policySearch holds the inputs to the exhaustive tree search
+type policySearch struct {
+	rows  [][]float64
+	gain  []float64 // score for treating minus score for not treating
+	order [][]int   // units sorted by each feature
+}
+
+// best returns the largest achievable total gain over the units in the
+// mask with a tree of the given depth, and that tree. Gains are measured
+// against treating nobody.
+func (s *policySearch) best(in []bool, depth int) (float64, *PolicyTree) {
+	var total float64
+	for i, ok := range in {
+		if ok {
+			total += s.gain[i]
+		}
+	}
+	bestReward := total
+	if bestReward < 0 {
+		bestReward = 0
+	}
+	tree := &PolicyTree{Treat: total > 0}
+	if depth == 0 {
+		return bestReward, tree
+	}
+
+	for f, order := range s.order {
+		units := make([]int, 0, len(order))
+		for _, i := range order {
+			if in[i] {
+				units = append(units, i)
+			}
+		}
+
+		var left []bool
+		if depth > 1 {
+			left = make([]bool, len(in))
+		}
+		var prefix float64
+		for k := 0; k < len(units)-1; k++ {
+			i := units[k]
+			prefix += s.gain[i]
+			if left != nil {
+				left[i] = true
+			}
+			// Only split between distinct values
+			if s.rows[units[k+1]][f] == s.rows[i][f] {
+				continue
+			}
+
+			var reward float64
+			var l, r *PolicyTree
+			if depth == 1 {
+				// Each side becomes a leaf that treats when its total gain is positive
+				lr, rr := prefix, total-prefix
+				l, r = &PolicyTree{Treat: lr > 0}, &PolicyTree{Treat: rr > 0}
+				if lr > 0 {
+					reward += lr
+				}
+				if rr > 0 {
+					reward += rr
+				}
+			} else {
+				right := make([]bool, len(in))
+				for _, j := range units[k+1:] {
+					right[j] = true
+				}
+				var lr, rr float64
+				lr, l = s.best(left, depth-1)
+				rr, r = s.best(right, depth-1)
+				reward = lr + rr
+			}
+			if reward > bestReward {
+				bestReward = reward
+				tree = &PolicyTree{
+					Feature:   f,
+					Threshold: (s.rows[i][f] + s.rows[units[k+1]][f]) / 2,
+					Left:      l,
+					Right:     r,
+				}
+			}
+		}
+	}
+	return bestReward, tree
+}
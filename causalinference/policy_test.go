@@ -0,0 +1,65 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// heterogeneousData has effect x, so treating exactly when x > 0 is optimal
+func heterogeneousData(n int, seed int64) *CausalData {
+	rng := rand.New(rand.NewSource(seed))
+	data := &CausalData{X: make([][]float64, n), Treatment: make([]int, n), Outcome: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		x := rng.NormFloat64()
+		data.X[i] = []float64{x}
+		if rng.Float64() < sigmoid(0.5*x) {
+			data.Treatment[i] = 1
+		}
+		data.Outcome[i] = x + x*float64(data.Treatment[i]) + rng.NormFloat64()
+	}
+	return data
+}
+
+func TestLearnPolicy(t *testing.T) {
+	train := heterogeneousData(1000, 1)
+
+	res, err := LearnPolicy(train, PolicyOptions{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tree.Left == nil || math.Abs(res.Tree.Threshold) > 0.25 {
+		t.Fatalf("expected a split near zero, got %+v", res.Tree)
+	}
+	if res.Tree.Left.Treat || !res.Tree.Right.Treat {
+		t.Error("tree should treat only high x")
+	}
+
+	// Evaluate on fresh data: E[x 1(x > 0)] = 1/sqrt(2 pi)
+	test := heterogeneousData(4000, 2)
+	assign := make([]int, len(test.X))
+	for i, x := range test.Column(0) {
+		assign[i] = res.Tree.Assign([]float64{x})
+	}
+	value, err := EvaluatePolicy(test, assign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 1 / math.Sqrt(2*math.Pi)
+	if math.Abs(value.Estimate-want) > 3*value.SE+0.02 {
+		t.Errorf("policy value %.3f (SE %.3f), want %.3f", value.Estimate, value.SE, want)
+	}
+
+	// A depth-2 tree can only do at least as well in sample
+	deep, err := LearnPolicy(train, PolicyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deep.Gain.Estimate < res.Gain.Estimate-1e-9 {
+		t.Errorf("depth 2 gain %.4f below depth 1 gain %.4f", deep.Gain.Estimate, res.Gain.Estimate)
+	}
+
+	if _, err := EvaluatePolicy(test, assign[:10]); err != ErrLengthMismatch {
+		t.Errorf("expected ErrLengthMismatch, got %v", err)
+	}
+}
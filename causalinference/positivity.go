@@ -0,0 +1,107 @@
+package causalinference
+
+import "sort"
+
+// OverlapOptions configures the positivity checks of CheckOverlap
+type OverlapOptions struct {
+	Propensity PropensityModel // propensity model; defaults to logistic MLE
+	// Extreme flags scores below Extreme or above 1 - Extreme; 0 means 0.1,
+	// the trimming rule of Crump et al. (2009)
+	Extreme float64
+	// MaxShare is the share of units outside the common support, or with
+	// extreme scores, above which Warning is set; 0 means 0.05
+	MaxShare float64
+}
+
+// ScoreSummary describes the distribution of propensity scores in one arm
+type ScoreSummary struct {
+	Min, Q1, Median, Q3, Max float64
+	Mean                     float64
+	N                        int // units in the arm
+}
+
+// OverlapDiagnostics reports how well the propensity score distributions
+// of the two arms overlap
+type OverlapDiagnostics struct {
+	Scores  []float64       // estimated propensity score of each unit
+	Arms    [2]ScoreSummary // score distribution by arm, indexed by treatment
+	Support [2]float64      // common support: the larger arm minimum to the smaller arm maximum
+	Outside int             // units whose score lies outside the common support
+	Extreme int             // units with a score beyond the Extreme threshold
+	// OutsideShare and ExtremeShare are Outside and Extreme over all units
+	OutsideShare, ExtremeShare float64
+	// Warning is set when either share exceeds MaxShare, a sign that
+	// weighting estimates rest on a few units and that trimming or a
+	// different estimand should be considered
+	Warning bool
+}
+
+// CheckOverlap estimates propensity scores and diagnoses positivity
+// violations: it summarizes the scores by arm, finds the common support
+// where both arms have units, and counts units outside it or with scores
+// near 0 or 1
+func CheckOverlap(data *CausalData, opts OverlapOptions) (OverlapDiagnostics, error) {
+	if err := requireBothArms(data); err != nil {
+		return OverlapDiagnostics{}, err
+	}
+	extreme := opts.Extreme
+	if extreme <= 0 {
+		extreme = 0.1
+	}
+	maxShare := opts.MaxShare
+	if maxShare <= 0 {
+		maxShare = 0.05
+	}
+
+	scores, err := weightingScores(data, opts.Propensity)
+	if err != nil {
+		return OverlapDiagnostics{}, err
+	}
+
+	diag := OverlapDiagnostics{Scores: scores}
+	for g := range diag.Arms {
+		diag.Arms[g] = summarizeScores(data.Treatment, scores, g)
+	}
+	diag.Support = [2]float64{diag.Arms[0].Min, diag.Arms[0].Max}
+	if diag.Arms[1].Min > diag.Support[0] {
+		diag.Support[0] = diag.Arms[1].Min
+	}
+	if diag.Arms[1].Max < diag.Support[1] {
+		diag.Support[1] = diag.Arms[1].Max
+	}
+
+	for _, p := range scores {
+		if p < diag.Support[0] || p > diag.Support[1] {
+			diag.Outside++
+		}
+		if p < extreme || p > 1-extreme {
+			diag.Extreme++
+		}
+	}
+	n := float64(len(scores))
+	diag.OutsideShare = float64(diag.Outside) / n
+	diag.ExtremeShare = float64(diag.Extreme) / n
+	diag.Warning = diag.OutsideShare > maxShare || diag.ExtremeShare > maxShare
+	return diag, nil
+}
+
+// summarizeScores describes the scores of the units in arm g
+func summarizeScores(treatment []int, scores []float64, g int) ScoreSummary {
+	var s []float64
+	for i, p := range scores {
+		if treatment[i] == g {
+			s = append(s, p)
+		}
+	}
+	sort.Float64s(s)
+	mean, _ := meanAndSE(s)
+	return ScoreSummary{
+		Min:    s[0],
+		Q1:     sortedQuantile(s, 0.25),
+		Median: sortedQuantile(s, 0.5),
+		Q3:     sortedQuantile(s, 0.75),
+		Max:    s[len(s)-1],
+		Mean:   mean,
+		N:      len(s),
+	}
+}
@@ -0,0 +1,34 @@
+package causalinference
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCheckOverlap(t *testing.T) {
+	// Treatment is nearly deterministic at extreme X in the standard DGP
+	data := GenerateCausalData(3000, 123)
+	diag, err := CheckOverlap(data, OverlapOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diag.Warning || diag.Extreme == 0 || diag.Arms[1].Median <= diag.Arms[0].Median {
+		t.Errorf("expected an overlap warning: %+v", diag.Arms)
+	}
+	if diag.Support[0] < diag.Arms[1].Min || diag.Support[1] > diag.Arms[0].Max || diag.Arms[0].N+diag.Arms[1].N != 3000 {
+		t.Errorf("common support %v outside the arm ranges", diag.Support)
+	}
+
+	// A randomized experiment has no positivity problem
+	rng := rand.New(rand.NewSource(1))
+	for i := range data.Treatment {
+		data.Treatment[i] = rng.Intn(2)
+	}
+	diag, err = CheckOverlap(data, OverlapOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diag.Warning || diag.Extreme != 0 {
+		t.Errorf("unexpected warning: %d outside, %d extreme", diag.Outside, diag.Extreme)
+	}
+}
@@ -0,0 +1,133 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInvalidPower is returned when a power calculation gets a
+// non-positive effect, standard deviation or sample size, or an alpha or
+// power outside (0, 1)
+var ErrInvalidPower = errors.New("causalinference: power analysis needs positive sizes and probabilities in (0, 1)")
+
+// PowerResult is the sample size a two-arm experiment needs
+type PowerResult struct {
+	NPerArm int     // units needed in each arm, rounded up
+	N       int     // total units across both arms
+	Power   float64 // power achieved with NPerArm units per arm
+}
+
+// PowerAnalysis returns the sample size at which a two-sided Welch test at
+// level alpha detects a difference in means of effect, with outcome
+// standard deviation sd in both arms and equal allocation, with
+// probability power. Like R's power.t.test it counts the rejections in
+// both tails, using a shifted central t in place of the noncentral t.
+func PowerAnalysis(effect, sd, alpha, power float64) (PowerResult, error) {
+	if effect <= 0 || sd <= 0 || !isProbability(alpha) || !isProbability(power) {
+		return PowerResult{}, ErrInvalidPower
+	}
+
+	// The t test needs at least the size given by the normal
+	// approximation, so search upward from just below it
+	z := normalQuantile(1-alpha/2) + normalQuantile(power)
+	n := int(2*z*z*sd*sd/(effect*effect)) - 1
+	if n < 2 {
+		n = 2
+	}
+	for twoSamplePower(effect, sd, alpha, n) < power {
+		n++
+	}
+	return PowerResult{NPerArm: n, N: 2 * n, Power: twoSamplePower(effect, sd, alpha, n)}, nil
+}
+
+// MinimumDetectableEffect returns the smallest difference in means that an
+// experiment with n units split equally between the arms detects with the
+// given power, under the same test as PowerAnalysis
+func MinimumDetectableEffect(n int, sd, alpha, power float64) (float64, error) {
+	if n < 4 || sd <= 0 || !isProbability(alpha) || !isProbability(power) {
+		return 0, ErrInvalidPower
+	}
+
+	// Power increases with the effect, so bisect between zero and an
+	// effect of ten standard errors
+	perArm := n / 2
+	lo, hi := 0.0, 10*sd*math.Sqrt(2/float64(perArm))
+	for k := 0; k < 100; k++ {
+		mid := (lo + hi) / 2
+		if twoSamplePower(mid, sd, alpha, perArm) < power {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
+// twoSamplePower is the power of the two-sided test at level alpha with n
+// units per arm
+func twoSamplePower(effect, sd, alpha float64, n int) float64 {
+	df := float64(2*n - 2)
+	shift := effect / (sd * math.Sqrt(2/float64(n)))
+	crit := studentTQuantile(1-alpha/2, df)
+	return 1 - studentTCDF(crit-shift, df) + studentTCDF(-crit-shift, df)
+}
+
+// isProbability reports whether p lies strictly between 0 and 1
+func isProbability(p float64) bool {
+	return p > 0 && p < 1
+}
+
+// SimulationPowerOptions configures simulation-based power analysis
+type SimulationPowerOptions struct {
+	Replications int     // simulated experiments; 0 means 500
+	Alpha        float64 // test level; 0 means 0.05
+	Seed         int64   // replication r uses seed Seed + r
+	// Generate draws a dataset of n units; nil means GenerateCausalData
+	Generate func(n int, seed int64) *CausalData
+}
+
+// SimulationPowerResult is the power estimated by simulation
+type SimulationPowerResult struct {
+	Power  float64 // share of replications whose p-value falls below Alpha
+	SE     float64 // Monte Carlo standard error of Power
+	Failed int     // replications where the estimator returned an error
+}
+
+// SimulatePower estimates the power of an estimator by drawing repeated
+// datasets of n units from the data generating process, estimating the
+// effect on each and counting how often its p-value falls below Alpha.
+// Unlike PowerAnalysis it accounts for covariate adjustment, confounding
+// and the estimator's own standard error.
+func SimulatePower(estimate Estimator, n int, opts SimulationPowerOptions) (SimulationPowerResult, error) {
+	reps := opts.Replications
+	if reps < 1 {
+		reps = 500
+	}
+	alpha := opts.Alpha
+	if alpha == 0 {
+		alpha = 0.05
+	}
+	if n < 4 || !isProbability(alpha) {
+		return SimulationPowerResult{}, ErrInvalidPower
+	}
+	results, _, errs := simulateResults(estimate, n, reps, opts.Seed, opts.Generate)
+
+	var res SimulationPowerResult
+	var rejected int
+	for r, err := range errs {
+		if err != nil {
+			res.Failed++
+			continue
+		}
+		if results[r].PValue < alpha {
+			rejected++
+		}
+	}
+	done := reps - res.Failed
+	if done == 0 {
+		return res, errs[0]
+	}
+	res.Power = float64(rejected) / float64(done)
+	res.SE = math.Sqrt(res.Power * (1 - res.Power) / float64(done))
+	return res, nil
+}
@@ -0,0 +1,53 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestPowerAnalysis(t *testing.T) {
+	// power.t.test(delta = 0.5, sd = 1, power = 0.8) gives n = 63.77 per arm
+	res, err := PowerAnalysis(0.5, 1, 0.05, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.NPerArm != 64 || res.N != 128 || res.Power < 0.8 || res.Power > 0.81 {
+		t.Errorf("power analysis %+v, want 64 per arm", res)
+	}
+
+	// With the same design the detectable effect is just below 0.5
+	mde, err := MinimumDetectableEffect(128, 1, 0.05, 0.8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mde > 0.5 || mde < 0.49 {
+		t.Errorf("MDE %.4f, want just below 0.5", mde)
+	}
+
+	if _, err := PowerAnalysis(0.5, 1, 0.05, 1); err != ErrInvalidPower {
+		t.Errorf("expected ErrInvalidPower, got %v", err)
+	}
+}
+
+func TestSimulatePower(t *testing.T) {
+	// A randomized experiment with effect 0.5 and unit noise, sized for 80% power
+	generate := func(n int, seed int64) *CausalData {
+		rng := rand.New(rand.NewSource(seed))
+		data := &CausalData{X: make([][]float64, n), Treatment: make([]int, n), Outcome: make([]float64, n), TrueEffect: 0.5}
+		for i := range data.X {
+			data.X[i] = []float64{rng.NormFloat64()}
+			data.Treatment[i] = i % 2
+			data.Outcome[i] = 0.5*float64(data.Treatment[i]) + rng.NormFloat64()
+		}
+		return data
+	}
+
+	res, err := SimulatePower(EstimateCausalEffect, 128, SimulationPowerOptions{Replications: 400, Seed: 1, Generate: generate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Power-0.8) > 3.5*res.SE || res.Failed != 0 {
+		t.Errorf("simulated power %.3f (SE %.3f), want about 0.8", res.Power, res.SE)
+	}
+}
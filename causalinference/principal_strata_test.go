@@ -0,0 +1,32 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrincipalStrataEM(t *testing.T) {
+	data := GenerateNoncomplianceData(4000, 123)
+
+	res, err := EstimatePrincipalStrata(data, PrincipalStrataOptions{Bootstrap: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueLATE) > 4*res.SE {
+		t.Errorf("CACE %.3f (SE %.3f), want %.1f", res.Estimate, res.SE, data.TrueLATE)
+	}
+
+	// The DGP has 60% compliers, 20% always-takers and 20% never-takers
+	want := [3]float64{0.6, 0.2, 0.2}
+	for k, s := range res.Shares {
+		if math.Abs(s-want[k]) > 0.03 {
+			t.Errorf("stratum %d share %.3f, want %.1f", k, s, want[k])
+		}
+	}
+
+	// With normal outcomes the mixture model agrees closely with the Wald ratio
+	late, _ := EstimateLATE(data)
+	if math.Abs(res.Estimate-late.Estimate) > 2*late.SE {
+		t.Errorf("EM estimate %.3f far from Wald estimate %.3f", res.Estimate, late.Estimate)
+	}
+}
@@ -0,0 +1,55 @@
+package causalinference
+
+// EstimatePropensityScores fits a logistic regression of treatment on the
+// covariates and returns each unit's estimated probability of treatment.
+// It returns nil if the model cannot be fit (e.g. only one treatment arm).
+// For more than two arms use MultinomialPropensityScores.
+func EstimatePropensityScores(data *CausalData) []float64 {
+	scores, err := propensityScores(data)
+	if err != nil {
+		return nil
+	}
+	return scores
+}
+
+// propensityScores is EstimatePropensityScores with the fitting error exposed
+func propensityScores(data *CausalData) ([]float64, error) {
+	x := withIntercept(covariateRows(data))
+	y := make([]float64, len(data.Treatment))
+	for i, t := range data.Treatment {
+		if t != 0 && t != 1 {
+			return nil, ErrNonBinaryTreatment
+		}
+		y[i] = float64(t)
+	}
+
+	beta, err := fitLogistic(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(x))
+	for i, row := range x {
+		scores[i] = sigmoid(dot(row, beta))
+	}
+
+	return scores, nil
+}
+
+// covariateRows returns the covariates of each unit as a row vector
+func covariateRows(data *CausalData) [][]float64 {
+	rows := make([][]float64, len(data.X))
+	for i, x := range data.X {
+		rows[i] = append([]float64(nil), x...)
+	}
+	return rows
+}
+
+// withIntercept prepends a constant column to each row
+func withIntercept(rows [][]float64) [][]float64 {
+	out := make([][]float64, len(rows))
+	for i, row := range rows {
+		out[i] = append([]float64{1}, row...)
+	}
+	return out
+}
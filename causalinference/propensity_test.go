@@ -0,0 +1,33 @@
+package causalinference
+
+import "testing"
+
+func TestEstimatePropensityScores(t *testing.T) {
+	data := GenerateCausalData(2000, 123)
+	scores := EstimatePropensityScores(data)
+
+	if len(scores) != len(data.X) {
+		t.Fatalf("got %d scores, want %d", len(scores), len(data.X))
+	}
+
+	// Scores must be valid probabilities
+	for i, p := range scores {
+		if p <= 0 || p >= 1 {
+			t.Fatalf("score %d out of range: %f", i, p)
+		}
+	}
+
+	// Treatment is more likely for higher X, so scores should rise with X
+	lo, hi := 0, 0
+	for i := range data.X {
+		if data.X[i][0] < data.X[lo][0] {
+			lo = i
+		}
+		if data.X[i][0] > data.X[hi][0] {
+			hi = i
+		}
+	}
+	if scores[hi] <= scores[lo] {
+		t.Error("Propensity should increase with X")
+	}
+}
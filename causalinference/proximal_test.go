@@ -0,0 +1,28 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateProximal(t *testing.T) {
+	data := GenerateProximalData(5000, 42)
+
+	res, err := EstimateProximal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 3*res.SE {
+		t.Errorf("estimate %.3f (SE %.3f), want %.1f", res.Estimate, res.SE, data.TrueEffect)
+	}
+
+	// Adjusting for the proxy as if it were the confounder stays biased
+	x := make([][]float64, len(data.Outcome))
+	for i := range x {
+		x[i] = []float64{1, float64(data.Treatment[i]), data.W[i], data.X[i]}
+	}
+	coef, _ := fitOLS(x, data.Outcome)
+	if math.Abs(coef[1]-data.TrueEffect) < 0.3 {
+		t.Errorf("naive proxy adjustment %.3f should be biased", coef[1])
+	}
+}
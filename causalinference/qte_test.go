@@ -0,0 +1,35 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateQTE(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+	quantiles := []float64{0.25, 0.5, 0.75}
+
+	naive, err := EstimateQTE(data, quantiles, QTEOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	weighted, err := EstimateQTE(data, quantiles, QTEOptions{Weighted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The effect is a constant shift, so every quantile effect equals it;
+	// weighting should move the median effect toward the truth
+	if math.Abs(weighted[1].Effect-data.TrueEffect) >= math.Abs(naive[1].Effect-data.TrueEffect) {
+		t.Errorf("weighted median effect %.3f no better than naive %.3f", weighted[1].Effect, naive[1].Effect)
+	}
+	for _, e := range weighted {
+		if math.Abs(e.Effect-(e.Treated-e.Control)) > 1e-12 {
+			t.Errorf("effect at q=%.2f is not Treated - Control", e.Quantile)
+		}
+	}
+
+	if _, err := EstimateQTE(data, []float64{1}, QTEOptions{}); err != ErrInvalidQuantile {
+		t.Errorf("expected ErrInvalidQuantile, got %v", err)
+	}
+}
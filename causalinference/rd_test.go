@@ -0,0 +1,72 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSharpRD(t *testing.T) {
+	data := GenerateRDData(5000, 42)
+
+	for _, k := range []Kernel{KernelTriangular, KernelEpanechnikov, KernelUniform} {
+		res, err := EstimateSharpRD(data, RDOptions{Bandwidth: 0.3, Kernel: k})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(res.Estimate-data.TrueEffect) > 0.25 {
+			t.Errorf("kernel %d: RD estimate %.4f too far from %.1f", k, res.Estimate, data.TrueEffect)
+		}
+	}
+
+	// The default bandwidth should still leave enough data to estimate
+	if _, err := EstimateSharpRD(data, RDOptions{}); err != nil {
+		t.Errorf("default bandwidth failed: %v", err)
+	}
+	if _, err := EstimateSharpRD(data, RDOptions{Bandwidth: 1e-6}); err != ErrNoObservations {
+		t.Errorf("expected ErrNoObservations, got %v", err)
+	}
+}
+
+func TestFuzzyRD(t *testing.T) {
+	data := GenerateFuzzyRDData(20000, 42)
+
+	res, err := EstimateFuzzyRD(data, RDOptions{Bandwidth: 0.4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("fuzzy RD CI %v does not cover %.1f (estimate %.4f)", res.CI, data.TrueEffect, res.Estimate)
+	}
+
+	// The sharp estimator only sees the jump in take-up times the effect
+	sharp, err := EstimateSharpRD(data, RDOptions{Bandwidth: 0.4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sharp.Estimate-data.TrueEffect) < math.Abs(res.Estimate-data.TrueEffect) {
+		t.Error("expected sharp RD to be biased on a fuzzy design")
+	}
+}
+
+func TestRDOptions(t *testing.T) {
+	fuzzy := DefaultRDConfig()
+	WithTakeUp(0.1, 0.6)(&fuzzy)
+	if fuzzy.Sharp() || !DefaultRDConfig().Sharp() {
+		t.Error("Sharp misclassifies the designs")
+	}
+
+	data := GenerateRDData(20000, 7, WithCutoff(50), WithRDEffect(-2), WithRDNoiseSD(0.3), WithTakeUp(0.1, 0.6))
+	for _, r := range data.Running {
+		if r < 49 || r > 51 {
+			t.Fatalf("running variable %.3f outside [49, 51]", r)
+		}
+	}
+
+	res, err := EstimateFuzzyRD(data, RDOptions{Bandwidth: 0.4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("fuzzy RD CI %v does not cover %.1f", res.CI, data.TrueEffect)
+	}
+}
@@ -0,0 +1,263 @@
+package causalinference
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+// ErrLengthMismatch is returned when a supplied column has the wrong length
+var ErrLengthMismatch = errors.New("causalinference: column length does not match the data")
+
+// Estimator computes an effect estimate from a dataset. Refuters and
+// resampling methods take an Estimator so any method in the package, or a
+// user's own, can be checked the same way; wrap an estimator with options
+// in a closure.
+type Estimator func(data *CausalData) (EffectResult, error)
+
+// RefutationResult compares an estimate with the same estimator's output
+// on modified data: either data where the true effect is known to be zero,
+// or data where it is known to be unchanged
+type RefutationResult struct {
+	Refuter  string       // name of the refutation test
+	Original EffectResult // estimate on the original data
+	Refuted  EffectResult // estimate on the modified data
+	// Passed reports whether the modified data behaved as expected: its
+	// confidence interval covers zero when the effect was removed, or the
+	// original estimate when it was kept
+	Passed bool
+}
+
+// NegativeControlOptions configures RefuteNegativeControl
+type NegativeControlOptions struct {
+	// Outcome is a negative-control outcome unaffected by treatment; nil
+	// means one is simulated from the controls' outcome model
+	Outcome []float64
+	Seed    int64 // seed for simulating the negative control
+}
+
+// RefuteNegativeControl re-runs an estimator with the outcome replaced by
+// a negative control: an outcome that shares the confounding of the real
+// one but is known not to respond to treatment. A nonzero estimate then
+// points to residual confounding. Without a supplied control, one is
+// simulated as the fitted value of a linear regression of the outcome on
+// the covariates among controls plus a resampled control residual, which
+// mimics Y(0) for every unit.
+func RefuteNegativeControl(data *CausalData, estimate Estimator, opts NegativeControlOptions) (RefutationResult, error) {
+	original, err := estimate(data)
+	if err != nil {
+		return RefutationResult{}, err
+	}
+
+	control := opts.Outcome
+	if control == nil {
+		control, err = simulateNegativeControl(data, opts.Seed)
+		if err != nil {
+			return RefutationResult{}, err
+		}
+	} else if len(control) != len(data.Outcome) {
+		return RefutationResult{}, ErrLengthMismatch
+	}
+
+	nc := subsetData(data, allUnits(len(data.Outcome)))
+	copy(nc.Outcome, control)
+	nc.TrueEffect = 0
+	refuted, err := estimate(nc)
+	if err != nil {
+		return RefutationResult{}, err
+	}
+
+	return RefutationResult{
+		Refuter:  "NegativeControlOutcome",
+		Original: original,
+		Refuted:  refuted,
+		Passed:   refuted.CI[0] <= 0 && 0 <= refuted.CI[1],
+	}, nil
+}
+
+// simulateNegativeControl draws an outcome that depends on the covariates
+// like the controls' outcomes do, but not on treatment
+func simulateNegativeControl(data *CausalData, seed int64) ([]float64, error) {
+	rows := covariateRows(data)
+	var x [][]float64
+	var y []float64
+	for i, t := range data.Treatment {
+		if t == 0 {
+			x = append(x, rows[i])
+			y = append(y, data.Outcome[i])
+		}
+	}
+	if len(y) < 2 {
+		return nil, ErrEmptyArm
+	}
+	fit, err := regress(withIntercept(x), y)
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]float64, len(rows))
+	for i, row := range withIntercept(rows) {
+		out[i] = dot(row, fit.coef) + fit.resid[rng.Intn(len(fit.resid))]
+	}
+	return out, nil
+}
+
+// allUnits returns the indices 0..n-1
+func allUnits(n int) []int {
+	units := make([]int, n)
+	for i := range units {
+		units[i] = i
+	}
+	return units
+}
+
+// PlaceboOptions configures RefutePlacebo
+type PlaceboOptions struct {
+	Permutations int   // placebo treatment vectors to draw; 0 means 200
+	Seed         int64 // seed for the permutations
+}
+
+// PlaceboResult holds the placebo distribution of an estimator
+type PlaceboResult struct {
+	RefutationResult
+	Placebo []float64 // estimate under each permuted treatment, in draw order
+	PValue  float64   // two-sided permutation p-value of the original estimate
+}
+
+// RefutePlacebo re-runs an estimator with the treatment vector randomly
+// permuted, which breaks any link between treatment and outcome while
+// keeping the arm sizes. The placebo estimates should center on zero; the
+// original estimate's position in their distribution is the permutation
+// test of PermutationTest. Refuted summarizes the placebo distribution
+// with its mean, standard deviation and 95% percentile interval.
+// Permutations run in parallel, so the estimator must be safe for
+// concurrent use; results do not depend on scheduling.
+func RefutePlacebo(data *CausalData, estimate Estimator, opts PlaceboOptions) (PlaceboResult, error) {
+	b := opts.Permutations
+	if b < 1 {
+		b = 200
+	}
+
+	test, err := PermutationTest(data, estimate, b, PermutationOptions{Seed: opts.Seed})
+	if err != nil {
+		return PlaceboResult{}, err
+	}
+	original, placebo := test.Observed, test.Null
+
+	refuted := summarizeDraws(placebo, len(data.Outcome), original.Method)
+	return PlaceboResult{
+		RefutationResult: RefutationResult{
+			Refuter:  "PlaceboTreatment",
+			Original: original,
+			Refuted:  refuted,
+			Passed:   refuted.CI[0] <= 0 && 0 <= refuted.CI[1],
+		},
+		Placebo: placebo,
+		PValue:  test.PValue,
+	}, nil
+}
+
+// summarizeDraws describes a distribution of estimates by its mean,
+// standard deviation and 95% percentile interval
+func summarizeDraws(draws []float64, n int, method string) EffectResult {
+	var sum float64
+	for _, d := range draws {
+		sum += d
+	}
+	sorted := append([]float64(nil), draws...)
+	sort.Float64s(sorted)
+	return EffectResult{
+		Estimate: sum / float64(len(draws)),
+		SE:       stdDev(draws),
+		CI:       [2]float64{sortedQuantile(sorted, 0.025), sortedQuantile(sorted, 0.975)},
+		N:        n,
+		Method:   method,
+	}.tested()
+}
+
+// ResampleRefuterOptions configures RefuteRandomCommonCause and
+// RefuteSubset
+type ResampleRefuterOptions struct {
+	Simulations int     // modified datasets to draw; 0 means 100
+	Fraction    float64 // share of units kept by RefuteSubset; 0 means 0.8
+	Seed        int64   // simulation r uses seed Seed + r
+}
+
+// simulations resolves the default number of simulations
+func (o ResampleRefuterOptions) simulations() int {
+	if o.Simulations < 1 {
+		return 100
+	}
+	return o.Simulations
+}
+
+// RefuteRandomCommonCause re-runs an estimator with an extra covariate of
+// independent standard normal noise. Adjusting for a variable unrelated
+// to treatment and outcome should leave the estimate unchanged, so the
+// refutation passes when the 95% percentile interval of the re-estimates
+// covers the original estimate. Simulations run in parallel.
+func RefuteRandomCommonCause(data *CausalData, estimate Estimator, opts ResampleRefuterOptions) (RefutationResult, error) {
+	original, err := estimate(data)
+	if err != nil {
+		return RefutationResult{}, err
+	}
+
+	n := len(data.Outcome)
+	return resampleRefutation("RandomCommonCause", original, estimate, opts.simulations(), n, func(r int) *CausalData {
+		rng := rand.New(rand.NewSource(opts.Seed + int64(r)))
+		out := subsetData(data, allUnits(n))
+		for i, row := range out.X {
+			out.X[i] = append(append([]float64(nil), row...), rng.NormFloat64())
+		}
+		if data.Names != nil {
+			out.Names = append(append([]string(nil), data.Names...), "RandomCommonCause")
+		}
+		return out
+	})
+}
+
+// RefuteSubset re-runs an estimator on random subsets holding Fraction of
+// the units, drawn without replacement. A stable estimator should give
+// similar answers on every subset, so the refutation passes when the 95%
+// percentile interval of the subset estimates covers the original
+// estimate. Simulations run in parallel.
+func RefuteSubset(data *CausalData, estimate Estimator, opts ResampleRefuterOptions) (RefutationResult, error) {
+	original, err := estimate(data)
+	if err != nil {
+		return RefutationResult{}, err
+	}
+
+	fraction := opts.Fraction
+	if fraction <= 0 || fraction > 1 {
+		fraction = 0.8
+	}
+	n := len(data.Outcome)
+	keep := int(fraction * float64(n))
+	return resampleRefutation("DataSubset", original, estimate, opts.simulations(), keep, func(r int) *CausalData {
+		rng := rand.New(rand.NewSource(opts.Seed + int64(r)))
+		units := rng.Perm(n)[:keep]
+		sort.Ints(units)
+		return subsetData(data, units)
+	})
+}
+
+// resampleRefutation runs the estimator on count modified datasets of n
+// units and checks that their percentile interval covers the original
+// estimate
+func resampleRefutation(refuter string, original EffectResult, estimate Estimator, count, n int, dataset func(int) *CausalData) (RefutationResult, error) {
+	draws, errs := parallelEstimates(estimate, count, dataset)
+	for _, err := range errs {
+		if err != nil {
+			return RefutationResult{}, err
+		}
+	}
+
+	refuted := summarizeDraws(draws, n, original.Method)
+	return RefutationResult{
+		Refuter:  refuter,
+		Original: original,
+		Refuted:  refuted,
+		Passed:   refuted.CI[0] <= original.Estimate && original.Estimate <= refuted.CI[1],
+	}, nil
+}
@@ -0,0 +1,70 @@
+package causalinference
+
+// Refuter runs one refutation test of an estimator on a dataset
+type Refuter func(data *CausalData, estimate Estimator) (RefutationResult, error)
+
+// PlaceboRefuter is RefutePlacebo as a Refuter
+func PlaceboRefuter(opts PlaceboOptions) Refuter {
+	return func(data *CausalData, estimate Estimator) (RefutationResult, error) {
+		res, err := RefutePlacebo(data, estimate, opts)
+		return res.RefutationResult, err
+	}
+}
+
+// NegativeControlRefuter is RefuteNegativeControl as a Refuter
+func NegativeControlRefuter(opts NegativeControlOptions) Refuter {
+	return func(data *CausalData, estimate Estimator) (RefutationResult, error) {
+		return RefuteNegativeControl(data, estimate, opts)
+	}
+}
+
+// RandomCommonCauseRefuter is RefuteRandomCommonCause as a Refuter
+func RandomCommonCauseRefuter(opts ResampleRefuterOptions) Refuter {
+	return func(data *CausalData, estimate Estimator) (RefutationResult, error) {
+		return RefuteRandomCommonCause(data, estimate, opts)
+	}
+}
+
+// SubsetRefuter is RefuteSubset as a Refuter
+func SubsetRefuter(opts ResampleRefuterOptions) Refuter {
+	return func(data *CausalData, estimate Estimator) (RefutationResult, error) {
+		return RefuteSubset(data, estimate, opts)
+	}
+}
+
+// RefutationSummary collects the results of a refutation suite
+type RefutationSummary struct {
+	Results []RefutationResult // result of each refuter, in the order given
+	Failed  []string           // names of the refuters that did not pass
+	Passed  bool               // whether every refuter passed
+}
+
+// Refute runs the estimator through a suite of refutation tests, in the
+// style of DoWhy's refute_estimate, and reports which of them it passed.
+// With no refuters it runs the placebo-treatment, random-common-cause,
+// data-subset and negative-control refuters with their default options.
+// The first refuter error stops the suite.
+func Refute(estimate Estimator, data *CausalData, refuters ...Refuter) (RefutationSummary, error) {
+	if len(refuters) == 0 {
+		refuters = []Refuter{
+			PlaceboRefuter(PlaceboOptions{}),
+			RandomCommonCauseRefuter(ResampleRefuterOptions{}),
+			SubsetRefuter(ResampleRefuterOptions{}),
+			NegativeControlRefuter(NegativeControlOptions{}),
+		}
+	}
+
+	summary := RefutationSummary{Passed: true}
+	for _, refute := range refuters {
+		res, err := refute(data, estimate)
+		if err != nil {
+			return RefutationSummary{}, err
+		}
+		summary.Results = append(summary.Results, res)
+		if !res.Passed {
+			summary.Failed = append(summary.Failed, res.Refuter)
+			summary.Passed = false
+		}
+	}
+	return summary, nil
+}
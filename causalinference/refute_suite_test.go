@@ -0,0 +1,26 @@
+package causalinference
+
+import "testing"
+
+func TestRefuteSuite(t *testing.T) {
+	data := GenerateCausalData(1000, 123)
+
+	summary, err := Refute(regressionEstimator, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Passed || len(summary.Results) != 4 || len(summary.Failed) != 0 {
+		t.Errorf("regression adjustment failed %v", summary.Failed)
+	}
+
+	// The unadjusted comparison fails only the negative control
+	summary, err = Refute(EstimateCausalEffect, data,
+		SubsetRefuter(ResampleRefuterOptions{Simulations: 20}),
+		NegativeControlRefuter(NegativeControlOptions{Seed: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Passed || len(summary.Failed) != 1 || summary.Failed[0] != "NegativeControlOutcome" {
+		t.Errorf("naive estimator failed %v", summary.Failed)
+	}
+}
@@ -0,0 +1,104 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+// regressionEstimator adapts EstimateRegressionAdjustment to the Estimator signature
+func regressionEstimator(data *CausalData) (EffectResult, error) {
+	return EstimateRegressionAdjustment(data, RegressionOptions{})
+}
+
+func TestRefuteNegativeControl(t *testing.T) {
+	data := GenerateCausalData(3000, 123)
+
+	// Adjusting for X removes the confounding shared by the negative control
+	res, err := RefuteNegativeControl(data, regressionEstimator, NegativeControlOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Passed {
+		t.Errorf("regression adjustment failed the negative control: %+v", res.Refuted)
+	}
+	if direct, _ := regressionEstimator(data); res.Original.Estimate != direct.Estimate {
+		t.Error("original estimate does not match a direct run")
+	}
+
+	// The unadjusted comparison picks up the confounding
+	naive, err := RefuteNegativeControl(data, EstimateCausalEffect, NegativeControlOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if naive.Passed {
+		t.Errorf("naive estimator passed the negative control: %+v", naive.Refuted)
+	}
+
+	// A supplied control is used as given and the data are left untouched
+	before := data.Outcome[0]
+	flat := make([]float64, len(data.Outcome))
+	res, _ = RefuteNegativeControl(data, EstimateCausalEffect, NegativeControlOptions{Outcome: flat})
+	if res.Refuted.Estimate != 0 || data.Outcome[0] != before {
+		t.Errorf("supplied control misused: estimate %.4f", res.Refuted.Estimate)
+	}
+}
+
+func TestRefutePlacebo(t *testing.T) {
+	data := GenerateCausalData(1000, 123)
+
+	res, err := RefutePlacebo(data, regressionEstimator, PlaceboOptions{Permutations: 99, Seed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Passed || math.Abs(res.Refuted.Estimate) > 0.2 {
+		t.Errorf("placebo distribution not centered on zero: %+v", res.Refuted)
+	}
+	// No permutation comes near a true effect of 5
+	if res.PValue != 0.01 {
+		t.Errorf("p-value = %.3f, want 0.01", res.PValue)
+	}
+
+	// Deterministic for a fixed seed
+	again, _ := RefutePlacebo(data, regressionEstimator, PlaceboOptions{Permutations: 99, Seed: 2})
+	for r := range res.Placebo {
+		if res.Placebo[r] != again.Placebo[r] {
+			t.Fatal("placebo draws depend on scheduling")
+		}
+	}
+
+	// With no effect the original estimate is unremarkable
+	null := subsetData(data, allUnits(len(data.Outcome)))
+	for i := range null.Outcome {
+		null.Outcome[i] -= data.TrueEffect * float64(data.Treatment[i])
+	}
+	res, _ = RefutePlacebo(null, regressionEstimator, PlaceboOptions{Permutations: 99, Seed: 2})
+	if res.PValue < 0.05 {
+		t.Errorf("null p-value = %.3f", res.PValue)
+	}
+}
+
+func TestRefuteRandomCommonCause(t *testing.T) {
+	data := GenerateCausalData(1000, 123)
+
+	res, err := RefuteRandomCommonCause(data, regressionEstimator, ResampleRefuterOptions{Simulations: 50, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The noise covariate moves the estimate only slightly, and the data
+	// keep their single covariate
+	if !res.Passed || math.Abs(res.Refuted.Estimate-res.Original.Estimate) > 0.02 || len(data.X[0]) != 1 {
+		t.Errorf("random common cause moved the estimate: %+v", res)
+	}
+}
+
+func TestRefuteSubset(t *testing.T) {
+	data := GenerateCausalData(1000, 123)
+
+	res, err := RefuteSubset(data, regressionEstimator, ResampleRefuterOptions{Simulations: 50, Seed: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Passed || res.Refuted.N != 800 || res.Refuted.SE <= 0 {
+		t.Errorf("subset refutation: %+v", res.Refuted)
+	}
+}
@@ -0,0 +1,167 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRegressionAdjustment(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	res, err := EstimateRegressionAdjustment(data, RegressionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The outcome is linear in X and treatment, so OLS should be unbiased
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.15 {
+		t.Errorf("OLS estimate %.4f too far from true effect %.1f", res.Estimate, data.TrueEffect)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v does not cover the true effect", res.CI)
+	}
+}
+
+func TestRegressionEstimands(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	// Effect grows with X: tau(x) = 5 + 2x, so each estimand differs
+	var sums, counts [2]float64
+	for i, x := range data.Column(0) {
+		g := data.Treatment[i]
+		if g == 1 {
+			data.Outcome[i] += 2 * x
+		}
+		sums[g] += x
+		counts[g]++
+	}
+	var all float64
+	for _, x := range data.Column(0) {
+		all += x / float64(len(data.X))
+	}
+
+	want := map[Estimand]float64{
+		EstimandATE: 5 + 2*all,
+		EstimandATT: 5 + 2*sums[1]/counts[1],
+		EstimandATC: 5 + 2*sums[0]/counts[0],
+	}
+	for estimand, target := range want {
+		res, err := EstimateRegressionAdjustment(data, RegressionOptions{Estimand: estimand})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(res.Estimate-target) > 4*res.SE {
+			t.Errorf("estimand %d: estimate %.4f, want %.4f", estimand, res.Estimate, target)
+		}
+	}
+}
+
+func TestLeastSquaresQRMatchesExactFit(t *testing.T) {
+	// y = 2 + 3x exactly, so QR must recover the coefficients
+	x := [][]float64{{1, 0}, {1, 1}, {1, 2}, {1, 3}}
+	y := []float64{2, 5, 8, 11}
+
+	coef, err := leastSquaresQR(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(coef[0]-2) > 1e-9 || math.Abs(coef[1]-3) > 1e-9 {
+		t.Errorf("unexpected coefficients %v", coef)
+	}
+}
+
+func TestRobustVariance(t *testing.T) {
+	// Reference values computed independently from the sandwich formulas
+	x := [][]float64{{1, 0}, {1, 1}, {1, 2}, {1, 3}, {1, 5}}
+	y := []float64{1, 3, 2, 5, 4}
+	fit, err := regress(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[VarianceType]float64{
+		VarianceClassical: 0.31931186663868977,
+		VarianceHC0:       0.1941576719433576,
+		VarianceHC1:       0.25065647665834234,
+		VarianceHC2:       0.3160395586103254,
+		VarianceHC3:       0.5516023900029239,
+	}
+	for kind, se := range want {
+		if got := math.Sqrt(fit.covariance(x, nil, kind)[1][1]); math.Abs(got-se) > 1e-12 {
+			t.Errorf("variance type %d: SE %.12f, want %.12f", kind, got, se)
+		}
+	}
+
+	// Robust errors grow when the noise is largest at high-leverage points
+	data := GenerateCausalData(3000, 5)
+	rng := rand.New(rand.NewSource(5))
+	for i, v := range data.Column(0) {
+		data.Outcome[i] += 2 * v * v * rng.NormFloat64()
+	}
+	classical, _ := EstimateRegressionAdjustment(data, RegressionOptions{})
+	hc3, _ := EstimateRegressionAdjustment(data, RegressionOptions{Variance: VarianceHC3})
+	if hc3.Estimate != classical.Estimate || hc3.SE <= classical.SE {
+		t.Errorf("HC3 SE %.4f not above classical %.4f", hc3.SE, classical.SE)
+	}
+}
+
+func TestClusterVariance(t *testing.T) {
+	// Cluster-level treatment with a shared cluster shock, 40 clusters of 10
+	rng := rand.New(rand.NewSource(9))
+	var x [][]float64
+	var y []float64
+	var cluster []int
+	var means [2][]float64
+	for g := 0; g < 40; g++ {
+		tr := float64(g % 2)
+		shock := rng.NormFloat64()
+		var sum float64
+		for k := 0; k < 10; k++ {
+			v := 2*tr + shock + rng.NormFloat64()
+			x = append(x, []float64{1, tr})
+			y = append(y, v)
+			cluster = append(cluster, g)
+			sum += v
+		}
+		means[g%2] = append(means[g%2], sum/10)
+	}
+	fit, err := regress(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With equal cluster sizes CR2 equals the variance of cluster means
+	_, se1 := meanAndSE(means[1])
+	_, se0 := meanAndSE(means[0])
+	cr2 := math.Sqrt(fit.covariance(x, cluster, VarianceCR2)[1][1])
+	if want := math.Sqrt(se1*se1 + se0*se0); math.Abs(cr2-want) > 1e-9 {
+		t.Errorf("CR2 SE %.6f, want %.6f", cr2, want)
+	}
+	hc0 := math.Sqrt(fit.covariance(x, nil, VarianceHC0)[1][1])
+	if cr0 := math.Sqrt(fit.covariance(x, cluster, VarianceCR0)[1][1]); cr0 < 2*hc0 || cr0 > cr2 {
+		t.Errorf("CR0 SE %.4f, HC0 %.4f, CR2 %.4f", cr0, hc0, cr2)
+	}
+
+	// Singleton clusters reduce CR2 to HC2
+	hc2 := fit.covariance(x, nil, VarianceHC2)[1][1]
+	if single := fit.covariance(x, nil, VarianceCR2)[1][1]; math.Abs(single-hc2) > 1e-12 {
+		t.Errorf("singleton CR2 %.8f, HC2 %.8f", single, hc2)
+	}
+
+	// The cluster column flows through the estimator and survives subsetting
+	data := &CausalData{X: make([][]float64, len(y)), Treatment: make([]int, len(y)), Outcome: y, Cluster: cluster}
+	for i, row := range x {
+		data.X[i] = []float64{rng.NormFloat64()}
+		data.Treatment[i] = int(row[1])
+	}
+	res, err := EstimateRegressionAdjustment(data, RegressionOptions{Variance: VarianceCR2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SE < 2*hc0 {
+		t.Errorf("clustered regression SE %.4f too small", res.SE)
+	}
+	if sub := subsetData(data, []int{15, 3}); sub.Cluster[0] != 1 || sub.Cluster[1] != 0 {
+		t.Errorf("subset clusters = %v", sub.Cluster)
+	}
+}
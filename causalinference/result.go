@@ -0,0 +1,247 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrEmptyArm is returned when the treatment or control group has no units
+var ErrEmptyArm = errors.New("causalinference: treatment or control group is empty")
+
+// ErrNonBinaryOutcome is returned when a ratio effect scale is requested
+// for an outcome that is not coded 0 or 1
+var ErrNonBinaryOutcome = errors.New("causalinference: effect scale requires a 0/1 outcome")
+
+// z975 is the 97.5th percentile of the standard normal distribution
+const z975 = 1.959963984540054
+
+// Estimand is the population over which a treatment effect is averaged
+type Estimand int
+
+const (
+	// EstimandDefault uses the estimator's conventional target: the ATE for
+	// weighting and regression, the ATT for matching
+	EstimandDefault Estimand = iota
+	// EstimandATE averages over the whole population
+	EstimandATE
+	// EstimandATT averages over the treated units
+	EstimandATT
+	// EstimandATC averages over the control units
+	EstimandATC
+)
+
+// or resolves EstimandDefault to the given estimand
+func (e Estimand) or(def Estimand) Estimand {
+	if e == EstimandDefault {
+		return def
+	}
+	return e
+}
+
+// EffectScale selects how the mean potential outcomes of two arms are
+// contrasted
+type EffectScale int
+
+const (
+	// ScaleDifference reports the difference in means (the risk difference
+	// for binary outcomes)
+	ScaleDifference EffectScale = iota
+	// ScaleRiskRatio reports the ratio of the mean outcomes
+	ScaleRiskRatio
+	// ScaleOddsRatio reports the ratio of the odds of the outcome
+	ScaleOddsRatio
+)
+
+// contrast returns the effect of mean m1 versus m0 on scale s
+func (s EffectScale) contrast(m1, m0 float64) float64 {
+	switch s {
+	case ScaleRiskRatio:
+		return m1 / m0
+	case ScaleOddsRatio:
+		return (m1 / (1 - m1)) / (m0 / (1 - m0))
+	default:
+		return m1 - m0
+	}
+}
+
+// requireBinaryOutcome returns ErrNonBinaryOutcome unless every y is 0 or 1
+func requireBinaryOutcome(y []float64) error {
+	for _, v := range y {
+		if v != 0 && v != 1 {
+			return ErrNonBinaryOutcome
+		}
+	}
+	return nil
+}
+
+// EffectResult holds a point estimate together with its uncertainty
+type EffectResult struct {
+	Estimate float64     // point estimate of the effect
+	SE       float64     // standard error of the estimate
+	CI       [2]float64  // 95% confidence interval
+	N        int         // number of units used
+	Method   string      // name of the estimator
+	Scale    EffectScale // scale of Estimate; the zero value is a difference
+	// DF is the degrees of freedom of the t reference distribution behind
+	// the interval and p-value; 0 means the standard normal
+	DF        float64
+	Null      float64 // effect under the null hypothesis: 0 for differences, 1 for ratios unless retested
+	Statistic float64 // test statistic for Null, on the log scale for ratios
+	PValue    float64 // two-sided p-value for Null
+	// Equivalence is the result of TestEquivalence or TestNonInferiority;
+	// the zero value means no such test was run
+	Equivalence EquivalenceTest
+}
+
+// EquivalenceTest is a two one-sided tests (TOST) procedure showing that an
+// effect lies within margins
+type EquivalenceTest struct {
+	Margins    [2]float64 // smallest and largest effects considered equivalent
+	Statistics [2]float64 // t statistics against the lower and upper margins
+	// PValue is the larger of the two one-sided p-values; below alpha the
+	// effect is shown to lie within the margins, which is the same as the
+	// 1 - 2 alpha interval lying inside them
+	PValue float64
+}
+
+// Test returns a copy of r with the test statistic and two-sided p-value
+// computed against the given null value. Differences are tested as
+// (Estimate - null)/SE; ratios as log(Estimate/null) over the log-scale
+// standard error SE/Estimate. The reference distribution is t with DF
+// degrees of freedom, or normal when DF is 0.
+func (r EffectResult) Test(null float64) EffectResult {
+	r.Null = null
+	if r.Scale == ScaleDifference {
+		r.Statistic = (r.Estimate - null) / r.SE
+	} else {
+		r.Statistic = math.Log(r.Estimate/null) / (r.SE / r.Estimate)
+	}
+	z := math.Abs(r.Statistic)
+	if r.DF > 0 {
+		r.PValue = 2 * (1 - studentTCDF(z, r.DF))
+	} else {
+		r.PValue = 2 * (1 - normalCDF(z))
+	}
+	return r
+}
+
+// TestEquivalence returns a copy of r with a TOST equivalence test of
+// whether the effect lies between lower and upper, as in the TOSTER
+// package. Like Test, ratios are tested on the log scale and DF selects
+// the t reference distribution.
+func (r EffectResult) TestEquivalence(lower, upper float64) EffectResult {
+	est, se := r.Estimate, r.SE
+	lo, hi := lower, upper
+	if r.Scale != ScaleDifference {
+		est, se = math.Log(r.Estimate), r.SE/r.Estimate
+		lo, hi = math.Log(lower), math.Log(upper)
+	}
+	cdf := func(z float64) float64 {
+		if r.DF > 0 {
+			return studentTCDF(z, r.DF)
+		}
+		return normalCDF(z)
+	}
+
+	test := EquivalenceTest{
+		Margins:    [2]float64{lower, upper},
+		Statistics: [2]float64{(est - lo) / se, (est - hi) / se},
+	}
+	test.PValue = math.Max(1-cdf(test.Statistics[0]), cdf(test.Statistics[1]))
+	r.Equivalence = test
+	return r
+}
+
+// TestNonInferiority returns a copy of r with a one-sided test that the
+// effect is above margin, the worst acceptable effect, such as -0.1 for a
+// metric that may drop by at most 0.1. It is TestEquivalence with no upper
+// margin, so Equivalence.PValue is the p-value of the lower test.
+func (r EffectResult) TestNonInferiority(margin float64) EffectResult {
+	return r.TestEquivalence(margin, math.Inf(1))
+}
+
+// Significance returns R's significance code for the p-value: "***"
+// below 0.001, "**" below 0.01, "*" below 0.05, "." below 0.1 and ""
+// otherwise
+func (r EffectResult) Significance() string {
+	switch p := r.PValue; {
+	case p < 0.001:
+		return "***"
+	case p < 0.01:
+		return "**"
+	case p < 0.05:
+		return "*"
+	case p < 0.1:
+		return "."
+	default:
+		return ""
+	}
+}
+
+// null returns the no-effect value on scale s
+func (s EffectScale) null() float64 {
+	if s == ScaleDifference {
+		return 0
+	}
+	return 1
+}
+
+// tested is r tested against no effect on its scale
+func (r EffectResult) tested() EffectResult {
+	return r.Test(r.Scale.null())
+}
+
+// newEffectResult fills in a normal-approximation 95% confidence interval
+// and a test of no effect
+func newEffectResult(method string, estimate, se float64, n int) EffectResult {
+	return EffectResult{
+		Estimate: estimate,
+		SE:       se,
+		CI:       [2]float64{estimate - z975*se, estimate + z975*se},
+		N:        n,
+		Method:   method,
+	}.tested()
+}
+
+// scaledEffect contrasts two independent mean estimates m1 and m0, with
+// variances v1 and v0, on the given scale. Ratio scales get a delta-method
+// standard error and a confidence interval formed on the log scale.
+func scaledEffect(scale EffectScale, method string, m1, m0, v1, v0 float64, n int) EffectResult {
+	var logSE float64
+	switch scale {
+	case ScaleRiskRatio:
+		logSE = math.Sqrt(v1/(m1*m1) + v0/(m0*m0))
+	case ScaleOddsRatio:
+		d1, d0 := m1*(1-m1), m0*(1-m0)
+		logSE = math.Sqrt(v1/(d1*d1) + v0/(d0*d0))
+	default:
+		return newEffectResult(method, m1-m0, math.Sqrt(v1+v0), n)
+	}
+
+	est := scale.contrast(m1, m0)
+	return EffectResult{
+		Estimate: est,
+		SE:       est * logSE,
+		CI:       [2]float64{est * math.Exp(-z975*logSE), est * math.Exp(z975*logSE)},
+		N:        n,
+		Method:   method,
+		Scale:    scale,
+	}.tested()
+}
+
+// meanAndSE returns the sample mean of v and the standard error of that mean
+func meanAndSE(v []float64) (float64, float64) {
+	n := float64(len(v))
+	var sum float64
+	for _, x := range v {
+		sum += x
+	}
+	mean := sum / n
+
+	var ss float64
+	for _, x := range v {
+		ss += (x - mean) * (x - mean)
+	}
+
+	return mean, math.Sqrt(ss / (n - 1) / n)
+}
@@ -0,0 +1,115 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// binaryOutcomeData replaces the outcome of the standard DGP with a binary
+// one, P(Y = 1) = sigmoid(-1 + X + T), so treatment raises the risk
+func binaryOutcomeData(n int, seed int64) *CausalData {
+	data := GenerateCausalData(n, seed)
+	rng := rand.New(rand.NewSource(seed))
+	for i, x := range data.Column(0) {
+		data.Outcome[i] = 0
+		if rng.Float64() < sigmoid(-1+x+float64(data.Treatment[i])) {
+			data.Outcome[i] = 1
+		}
+	}
+	return data
+}
+
+func TestEffectScales(t *testing.T) {
+	data := binaryOutcomeData(4000, 123)
+
+	diff, err := EstimateWeighted(data, WeightingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := EstimateWeighted(data, WeightingOptions{Scale: ScaleRiskRatio})
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, err := EstimateWeighted(data, WeightingOptions{Scale: ScaleOddsRatio})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A harmful exposure has RD > 0, and the odds ratio exceeds the risk ratio
+	if !(diff.Estimate > 0 && rr.Estimate > 1 && or.Estimate > rr.Estimate) {
+		t.Errorf("RD %.3f, RR %.3f, OR %.3f out of order", diff.Estimate, rr.Estimate, or.Estimate)
+	}
+	// Ratio intervals are formed on the log scale, so they are asymmetric
+	for _, r := range []WeightingResult{rr, or} {
+		if !(r.CI[0] < r.Estimate && r.Estimate < r.CI[1]) || r.CI[0] <= 0 {
+			t.Errorf("invalid ratio CI %v around %.3f", r.CI, r.Estimate)
+		}
+		if math.Abs((r.CI[1]-r.Estimate)-(r.Estimate-r.CI[0])) < 1e-9 {
+			t.Errorf("ratio CI %v is symmetric", r.CI)
+		}
+	}
+
+	// g-computation reports the same scales from its standardised means
+	g, err := EstimateGComputation(data, GCompOptions{Bootstrap: 50, Scale: ScaleRiskRatio})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(math.Log(g.Estimate)-math.Log(rr.Estimate)) > 0.1 {
+		t.Errorf("g-computation RR %.3f far from IPW RR %.3f", g.Estimate, rr.Estimate)
+	}
+
+	// Ratio scales need a binary outcome
+	_, err = EstimateWeighted(GenerateCausalData(100, 1), WeightingOptions{Scale: ScaleOddsRatio})
+	if err != ErrNonBinaryOutcome {
+		t.Errorf("expected ErrNonBinaryOutcome, got %v", err)
+	}
+}
+
+func TestEffectTest(t *testing.T) {
+	r := newEffectResult("test", 0.4, 0.2, 100)
+	if math.Abs(r.Statistic-2) > 1e-12 || math.Abs(r.PValue-0.0455003) > 1e-6 || r.Significance() != "*" {
+		t.Errorf("z = %.4f, p = %.6f, code %q", r.Statistic, r.PValue, r.Significance())
+	}
+	if at := r.Test(0.4); at.Statistic != 0 || at.PValue != 1 || at.Null != 0.4 {
+		t.Errorf("test at the estimate: %+v", at)
+	}
+
+	// Ratios are tested against 1 on the log scale
+	rr := scaledEffect(ScaleRiskRatio, "test", 0.3, 0.2, 1e-4, 1e-4, 100)
+	if rr.Null != 1 || math.Abs(rr.Statistic-math.Log(rr.Estimate)/(rr.SE/rr.Estimate)) > 1e-12 {
+		t.Errorf("ratio test: %+v", rr)
+	}
+
+	// t with one degree of freedom is the Cauchy distribution
+	for _, x := range []float64{-3, -0.5, 0, 1, 12} {
+		if got, want := studentTCDF(x, 1), 0.5+math.Atan(x)/math.Pi; math.Abs(got-want) > 1e-10 {
+			t.Errorf("studentTCDF(%g, 1) = %.10f, want %.10f", x, got, want)
+		}
+	}
+	if q := studentTQuantile(0.975, 10); math.Abs(q-2.228139) > 1e-6 {
+		t.Errorf("t quantile %.6f, want 2.228139", q)
+	}
+}
+
+func TestEquivalenceTest(t *testing.T) {
+	r := newEffectResult("test", 0.05, 0.1, 100)
+
+	// The 90% interval [-0.114, 0.214] lies inside +-0.25 but not +-0.2
+	eq := r.TestEquivalence(-0.25, 0.25).Equivalence
+	if eq.PValue >= 0.05 || math.Abs(eq.Statistics[0]-3) > 1e-12 || math.Abs(eq.Statistics[1]+2) > 1e-12 {
+		t.Errorf("equivalence within 0.25: %+v", eq)
+	}
+	if want := normalCDF(-2); math.Abs(eq.PValue-want) > 1e-12 {
+		t.Errorf("TOST p-value %.6f, want %.6f", eq.PValue, want)
+	}
+	if eq := r.TestEquivalence(-0.2, 0.2).Equivalence; eq.PValue < 0.05 {
+		t.Errorf("equivalence within 0.2 shown: %+v", eq)
+	}
+
+	// Non-inferiority only tests the lower margin
+	ni := r.TestNonInferiority(-0.15).Equivalence
+	if want := 1 - normalCDF(2); math.Abs(ni.PValue-want) > 1e-12 || r.Equivalence.PValue != 0 {
+		t.Errorf("non-inferiority p-value %.6f, want %.6f", ni.PValue, want)
+	}
+}
@@ -0,0 +1,48 @@
+package causalinference
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRosenbaumBounds(t *testing.T) {
+	data := GenerateCausalData(1000, 123)
+	match, err := MatchNearestNeighbor(data, MatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := RosenbaumBounds(data, match, RosenbaumOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Bounds) != 5 || res.Bounds[0].PLower != res.Bounds[0].PUpper {
+		t.Fatalf("Gamma = 1 should give a single p-value: %+v", res.Bounds[0])
+	}
+	for k := 1; k < len(res.Bounds); k++ {
+		if res.Bounds[k].PUpper < res.Bounds[k-1].PUpper || res.Bounds[k].PLower > res.Bounds[k].PUpper {
+			t.Errorf("bounds not nested at Gamma %.1f: %+v", res.Bounds[k].Gamma, res.Bounds[k])
+		}
+	}
+	// An effect of five outcome SDs survives substantial hidden bias
+	if res.CriticalGamma < 3 {
+		t.Errorf("critical Gamma %.2f, want large for a strong effect", res.CriticalGamma)
+	}
+
+	// Without an effect the result is sensitive to any hidden bias
+	rng := rand.New(rand.NewSource(1))
+	for i := range data.Outcome {
+		data.Outcome[i] = rng.NormFloat64()
+	}
+	null, _ := RosenbaumBounds(data, match, RosenbaumOptions{})
+	if null.CriticalGamma >= res.CriticalGamma || null.CriticalGamma > 2 {
+		t.Errorf("null critical Gamma %.2f, want near 1", null.CriticalGamma)
+	}
+}
+
+func TestSignedRankStatistic(t *testing.T) {
+	// Ranks of |d| are 1, 2.5, 2.5, 4; positives hold ranks 2.5 and 4
+	if got := signedRankStatistic([]float64{-0.5, 1, -1, 3}); got != 6.5 {
+		t.Errorf("statistic %.2f, want 6.5", got)
+	}
+}
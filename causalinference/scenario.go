@@ -0,0 +1,191 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ErrUnknownScenario is returned for a scenario name that is not registered
+var ErrUnknownScenario = errors.New("causalinference: unknown scenario")
+
+// Names of the registered simulation scenarios
+const (
+	ScenarioDefault = "default"
+	// ScenarioKangSchafer2007 observes only nonlinear transforms of the
+	// confounders, so linear propensity and outcome models are both wrong
+	ScenarioKangSchafer2007 = "kang-schafer-2007"
+	// ScenarioKangSchafer2007Correct observes the confounders themselves
+	ScenarioKangSchafer2007Correct = "kang-schafer-2007-correct"
+	ScenarioLunceford2004          = "lunceford-davidian-2004"
+	ScenarioACIC                   = "acic-style"
+)
+
+// Scenario is a named data generating process, such as a design from the
+// literature, so that results can be compared with published ones
+type Scenario struct {
+	Name        string
+	Description string
+	Generate    func(n int, seed int64) *CausalData
+}
+
+// scenarios holds the registry, keyed by name
+var scenarios = map[string]Scenario{
+	ScenarioDefault: {
+		Name:        ScenarioDefault,
+		Description: "GenerateCausalData with its defaults: one confounder, constant effect 5",
+		Generate:    func(n int, seed int64) *CausalData { return GenerateCausalData(n, seed) },
+	},
+	ScenarioKangSchafer2007: {
+		Name:        ScenarioKangSchafer2007,
+		Description: "Kang and Schafer (2007) with transformed covariates; effect 0",
+		Generate:    func(n int, seed int64) *CausalData { return generateKangSchafer(n, seed, true) },
+	},
+	ScenarioKangSchafer2007Correct: {
+		Name:        ScenarioKangSchafer2007Correct,
+		Description: "Kang and Schafer (2007) with the true covariates; effect 0",
+		Generate:    func(n int, seed int64) *CausalData { return generateKangSchafer(n, seed, false) },
+	},
+	ScenarioLunceford2004: {
+		Name:        ScenarioLunceford2004,
+		Description: "Lunceford and Davidian (2004), strong outcome-only covariates; effect 2",
+		Generate:    generateLunceford,
+	},
+	ScenarioACIC: {
+		Name:        ScenarioACIC,
+		Description: "ACIC-style: correlated and categorical covariates, nonlinear confounding, heterogeneous effects",
+		Generate:    generateACICStyle,
+	},
+}
+
+// LookupScenario returns the scenario registered under name
+func LookupScenario(name string) (Scenario, error) {
+	s, ok := scenarios[name]
+	if !ok {
+		return Scenario{}, ErrUnknownScenario
+	}
+	return s, nil
+}
+
+// Scenarios returns every registered scenario, ordered by name
+func Scenarios() []Scenario {
+	out := make([]Scenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// generateKangSchafer follows Kang and Schafer (2007): Z1..Z4 are standard
+// normal, treatment has logit -Z1 + 0.5Z2 - 0.25Z3 - 0.1Z4, and the outcome
+// is 210 + 27.4Z1 + 13.7(Z2 + Z3 + Z4) + N(0, 1) whatever the treatment, so
+// the effect is zero. When transformed is set the data hold only
+// exp(Z1/2), Z2/(1 + exp(Z1)) + 10, (Z1 Z3/25 + 0.6)^3 and (Z2 + Z4 + 20)^2.
+func generateKangSchafer(n int, seed int64, transformed bool) *CausalData {
+	rng := rand.New(rand.NewSource(seed))
+
+	data := &CausalData{
+		X:           make([][]float64, n),
+		Treatment:   make([]int, n),
+		Outcome:     make([]float64, n),
+		UnitEffects: make([]float64, n),
+	}
+	for i := 0; i < n; i++ {
+		z := make([]float64, 4)
+		for j := range z {
+			z[j] = rng.NormFloat64()
+		}
+		if rng.Float64() < sigmoid(-z[0]+0.5*z[1]-0.25*z[2]-0.1*z[3]) {
+			data.Treatment[i] = 1
+		}
+		data.Outcome[i] = 210 + 27.4*z[0] + 13.7*(z[1]+z[2]+z[3]) + rng.NormFloat64()
+
+		data.X[i] = z
+		if transformed {
+			data.X[i] = []float64{
+				math.Exp(z[0] / 2),
+				z[1]/(1+math.Exp(z[0])) + 10,
+				math.Pow(z[0]*z[2]/25+0.6, 3),
+				math.Pow(z[1]+z[3]+20, 2),
+			}
+		}
+	}
+	return data
+}
+
+// generateLunceford follows Lunceford and Davidian (2004): X3 ~
+// Bernoulli(0.2), V3 | X3 ~ Bernoulli(0.25 + 0.5 X3), and (X1, V1, X2, V2)
+// given X3 are normal with means (1, 1, -1, -1) when X3 = 1 and their
+// negatives otherwise, unit variances and correlations 0.5 within and -0.5
+// across the pairs. Treatment has logit 0.6X1 - 0.6X2 + 0.6X3 and the
+// outcome is -X1 + X2 - X3 + 2T - V1 + V2 + V3 + N(0, 1), the strong
+// association setting, so the effect is 2.
+func generateLunceford(n int, seed int64) *CausalData {
+	rng := rand.New(rand.NewSource(seed))
+
+	factor, err := cholesky([][]float64{
+		{1, 0.5, -0.5, -0.5},
+		{0.5, 1, -0.5, -0.5},
+		{-0.5, -0.5, 1, 0.5},
+		{-0.5, -0.5, 0.5, 1},
+	})
+	if err != nil {
+		panic("causalinference: Lunceford-Davidian covariance matrix is not positive definite")
+	}
+	data := &CausalData{
+		X:          make([][]float64, n),
+		Names:      []string{"X1", "X2", "X3", "V1", "V2", "V3"},
+		Treatment:  make([]int, n),
+		Outcome:    make([]float64, n),
+		TrueEffect: 2,
+	}
+	for i := 0; i < n; i++ {
+		var x3, v3 float64
+		if rng.Float64() < 0.2 {
+			x3 = 1
+		}
+		if rng.Float64() < 0.25+0.5*x3 {
+			v3 = 1
+		}
+		z := make([]float64, 4)
+		for j := range z {
+			z[j] = rng.NormFloat64()
+		}
+		w := matVec(factor, z)
+		sign := 2*x3 - 1
+		x1, v1, x2, v2 := w[0]+sign, w[1]+sign, w[2]-sign, w[3]-sign
+
+		if rng.Float64() < sigmoid(0.6*x1-0.6*x2+0.6*x3) {
+			data.Treatment[i] = 1
+		}
+		data.X[i] = []float64{x1, x2, x3, v1, v2, v3}
+		data.Outcome[i] = -x1 + x2 - x3 + 2*float64(data.Treatment[i]) - v1 + v2 + v3 + rng.NormFloat64()
+	}
+	return data
+}
+
+// generateACICStyle mimics the flavour of the ACIC data challenges (Dorie
+// et al., 2019) with the package's own options rather than their data:
+// five covariates with AR(1) correlation 0.5, a binary and a three-level
+// factor, quadratic confounding, noise that grows with the confounders,
+// and an effect 2 + x1 - 0.5 x2^2 that varies across units
+func generateACICStyle(n int, seed int64) *CausalData {
+	cov := make([][]float64, 5)
+	for j := range cov {
+		cov[j] = make([]float64, 5)
+		for k := range cov[j] {
+			cov[j][k] = math.Pow(0.5, math.Abs(float64(j-k)))
+		}
+	}
+	return GenerateCausalData(n, seed,
+		WithCovariance(cov, []float64{1, 1, 0.5, 0, 0}),
+		WithNonlinearity(NonlinearQuadratic),
+		WithCategorical("B", []float64{0.6, 0.4}, []float64{0, 0.5}),
+		WithCategorical("F", []float64{0.3, 0.4, 0.3}, []float64{-0.5, 0, 0.5}),
+		WithBaselinePropensity(0.4),
+		WithHeteroskedasticity(0.2, 1),
+		WithEffectFunction(func(x []float64) float64 { return 2 + x[0] - 0.5*x[1]*x[1] }),
+	)
+}
@@ -0,0 +1,44 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScenarios(t *testing.T) {
+	for _, s := range Scenarios() {
+		data := s.Generate(3000, 1)
+		if len(data.X) != 3000 || len(data.Outcome) != 3000 {
+			t.Fatalf("%s: %d rows", s.Name, len(data.X))
+		}
+		if err := requireBothArms(data); err != nil {
+			t.Errorf("%s: %v", s.Name, err)
+		}
+	}
+	if _, err := LookupScenario("no-such-design"); err != ErrUnknownScenario {
+		t.Errorf("error %v, want ErrUnknownScenario", err)
+	}
+
+	// Correctly specified regression recovers the published effects
+	for _, name := range []string{ScenarioKangSchafer2007Correct, ScenarioLunceford2004} {
+		s, err := LookupScenario(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := s.Generate(5000, 2)
+		res, err := EstimateRegressionAdjustment(data, RegressionOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(res.Estimate-data.TrueEffect) > 4*res.SE {
+			t.Errorf("%s: estimate %.3f (SE %.3f), want %.1f", name, res.Estimate, res.SE, data.TrueEffect)
+		}
+	}
+
+	// Kang and Schafer's raw comparison is badly confounded
+	ks, _ := LookupScenario(ScenarioKangSchafer2007)
+	naive, _ := EstimateCausalEffect(ks.Generate(5000, 2))
+	if math.Abs(naive.Estimate) < 10 {
+		t.Errorf("naive Kang-Schafer estimate %.2f, want large bias", naive.Estimate)
+	}
+}
@@ -0,0 +1,107 @@
+package causalinference
+
+import "math"
+
+// ConfidenceSequenceOptions configures a ConfidenceSequence
+type ConfidenceSequenceOptions struct {
+	Alpha float64 // error rate over the whole sequence; 0 means 0.05
+	// PlannedN is the total sample size at which the sequence is tuned to
+	// be narrowest; 0 means 1000. Any stopping time remains valid.
+	PlannedN int
+}
+
+// ConfidenceSequence tracks the difference in means of a stream of units
+// with an anytime-valid confidence sequence: with probability 1 - Alpha
+// the intervals at every sample size cover the true effect at once, so an
+// experiment can be monitored continuously and stopped whenever the
+// interval excludes zero. It uses the normal-mixture boundary of the
+// asymptotic confidence sequences of Waudby-Smith et al. (2023) around
+// the Welch standard error. The zero value is not usable; call
+// NewConfidenceSequence.
+type ConfidenceSequence struct {
+	alpha float64
+	rho2  float64 // mixture variance, tuned for PlannedN
+	count [2]int
+	mean  [2]float64
+	m2    [2]float64 // sum of squared deviations, updated by Welford's method
+	lower float64    // running intersection of the intervals
+	upper float64
+	p     float64 // running minimum of the anytime p-value
+}
+
+// NewConfidenceSequence returns an empty confidence sequence
+func NewConfidenceSequence(opts ConfidenceSequenceOptions) *ConfidenceSequence {
+	alpha := opts.Alpha
+	if alpha <= 0 || alpha >= 1 {
+		alpha = 0.05
+	}
+	planned := opts.PlannedN
+	if planned < 1 {
+		planned = 1000
+	}
+	l := -2 * math.Log(alpha)
+	return &ConfidenceSequence{
+		alpha: alpha,
+		rho2:  (l + math.Log(l+1)) / float64(planned),
+		lower: math.Inf(-1),
+		upper: math.Inf(1),
+		p:     1,
+	}
+}
+
+// Add records one unit's treatment (0 or 1) and outcome and updates the
+// interval
+func (cs *ConfidenceSequence) Add(treatment int, outcome float64) {
+	g := treatment
+	cs.count[g]++
+	d := outcome - cs.mean[g]
+	cs.mean[g] += d / float64(cs.count[g])
+	cs.m2[g] += d * (outcome - cs.mean[g])
+
+	if cs.count[0] < 2 || cs.count[1] < 2 {
+		return
+	}
+	est, se := cs.estimate()
+	n := float64(cs.count[0] + cs.count[1])
+	half := se * math.Sqrt(n*(n*cs.rho2+1)/(n*n*cs.rho2)*2*math.Log(math.Sqrt(n*cs.rho2+1)/cs.alpha))
+	cs.lower = math.Max(cs.lower, est-half)
+	cs.upper = math.Min(cs.upper, est+half)
+
+	// The mixture martingale against no effect; its inverse is an anytime p-value
+	s := math.Sqrt(n) * est / se
+	logM := cs.rho2*s*s/(2*(n*cs.rho2+1)) - 0.5*math.Log(n*cs.rho2+1)
+	cs.p = math.Min(cs.p, math.Min(1, math.Exp(-logM)))
+}
+
+// estimate returns the difference in means and its Welch standard error
+func (cs *ConfidenceSequence) estimate() (float64, float64) {
+	var v float64
+	for g := range cs.count {
+		n := float64(cs.count[g])
+		v += cs.m2[g] / (n - 1) / n
+	}
+	return cs.mean[1] - cs.mean[0], math.Sqrt(v)
+}
+
+// Result returns the current estimate with the confidence sequence as its
+// interval and the anytime-valid p-value for no effect. Until both arms
+// have two units the interval is unbounded and the p-value is 1.
+func (cs *ConfidenceSequence) Result() EffectResult {
+	res := EffectResult{
+		CI:     [2]float64{cs.lower, cs.upper},
+		N:      cs.count[0] + cs.count[1],
+		Method: "ConfidenceSequence",
+		PValue: cs.p,
+	}
+	if cs.count[0] >= 2 && cs.count[1] >= 2 {
+		res.Estimate, res.SE = cs.estimate()
+		res.Statistic = res.Estimate / res.SE
+	}
+	return res
+}
+
+// Rejected reports whether the sequence has excluded zero, which stays
+// true once it happens
+func (cs *ConfidenceSequence) Rejected() bool {
+	return cs.p <= cs.alpha
+}
@@ -0,0 +1,40 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestConfidenceSequence(t *testing.T) {
+	// The sequence is wider than the fixed-n interval at the planned size
+	data := GenerateCausalData(1000, 8)
+	cs := NewConfidenceSequence(ConfidenceSequenceOptions{})
+	for i, g := range data.Treatment {
+		cs.Add(g, data.Outcome[i])
+	}
+	res := cs.Result()
+	fixed, _ := EstimateCausalEffect(data)
+	if res.N != 1000 || math.Abs(res.Estimate-fixed.Estimate) > 1e-9 || res.CI[0] >= fixed.CI[0] || res.CI[1] <= fixed.CI[1] {
+		t.Errorf("sequence %v vs fixed interval %v", res.CI, fixed.CI)
+	}
+	if !cs.Rejected() || res.PValue > 1e-6 {
+		t.Errorf("large effect not detected: p = %g", res.PValue)
+	}
+
+	// Under the null, continuous monitoring rarely rejects at any time
+	var rejected int
+	for rep := 0; rep < 200; rep++ {
+		rng := rand.New(rand.NewSource(int64(rep)))
+		cs := NewConfidenceSequence(ConfidenceSequenceOptions{PlannedN: 200})
+		for i := 0; i < 500; i++ {
+			cs.Add(rng.Intn(2), rng.NormFloat64())
+		}
+		if cs.Rejected() {
+			rejected++
+		}
+	}
+	if rejected > 20 {
+		t.Errorf("%d of 200 null streams rejected", rejected)
+	}
+}
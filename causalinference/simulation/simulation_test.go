@@ -0,0 +1,53 @@
+package simulation
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"causalinference/causalinference"
+)
+
+func TestRun(t *testing.T) {
+	grid := Grid{
+		N:           []int{400},
+		Effect:      []float64{1},
+		Confounding: []float64{0, 1},
+		Estimators: []Estimator{
+			{"naive", causalinference.EstimateCausalEffect},
+			{"ols", func(data *causalinference.CausalData) (causalinference.EffectResult, error) {
+				return causalinference.EstimateRegressionAdjustment(data, causalinference.RegressionOptions{})
+			}},
+		},
+		Replications: 60,
+		Seed:         1,
+	}
+	rows, err := Run(grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[1].Estimator != "ols" || rows[2].Confounding != 1 {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+
+	// Without confounding both are unbiased; with it only adjustment is
+	for _, r := range rows {
+		biased := r.Confounding == 1 && r.Estimator == "naive"
+		if biased != (math.Abs(r.Bias) > 0.3) {
+			t.Errorf("%s at confounding %.0f: bias %.3f", r.Estimator, r.Confounding, r.Bias)
+		}
+		if !biased && r.Coverage < 0.85 {
+			t.Errorf("%s at confounding %.0f: coverage %.2f", r.Estimator, r.Confounding, r.Coverage)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[1], "400,1,0,naive,") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
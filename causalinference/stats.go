@@ -0,0 +1,128 @@
+package causalinference
+
+import (
+	"math"
+	"sort"
+)
+
+// quantile returns the q-th sample quantile of v using linear interpolation
+// between order statistics (R's default type 7). v is not modified.
+func quantile(v []float64, q float64) float64 {
+	s := append([]float64(nil), v...)
+	sort.Float64s(s)
+	return sortedQuantile(s, q)
+}
+
+// sortedQuantile is quantile for input that is already sorted ascending
+func sortedQuantile(s []float64, q float64) float64 {
+	if len(s) == 0 {
+		return math.NaN()
+	}
+	h := q * float64(len(s)-1)
+	lo := math.Floor(h)
+	hi := math.Ceil(h)
+	return s[int(lo)] + (h-lo)*(s[int(hi)]-s[int(lo)])
+}
+
+// stdDev returns the sample standard deviation of v
+func stdDev(v []float64) float64 {
+	_, se := meanAndSE(v)
+	return se * math.Sqrt(float64(len(v)))
+}
+
+// normalDensity returns the N(mean, sd^2) density at x
+func normalDensity(x, mean, sd float64) float64 {
+	z := (x - mean) / sd
+	return math.Exp(-z*z/2) / (sd * math.Sqrt(2*math.Pi))
+}
+
+// normalCDF returns the standard normal cumulative distribution at z
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// normalQuantile returns the standard normal quantile at probability p
+func normalQuantile(p float64) float64 {
+	return -math.Sqrt2 * math.Erfcinv(2*p)
+}
+
+// Settings for the continued fraction of the incomplete beta function
+const (
+	betaMaxIter   = 300
+	betaTolerance = 1e-14
+)
+
+// studentTCDF returns the cumulative distribution of Student's t with df
+// degrees of freedom at t
+func studentTCDF(t, df float64) float64 {
+	tail := 0.5 * incompleteBeta(df/2, 0.5, df/(df+t*t))
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// studentTQuantile returns the t quantile at probability p by bisection
+// on the distribution function
+func studentTQuantile(p, df float64) float64 {
+	lo, hi := -1e3, 1e3
+	for k := 0; k < 200 && hi-lo > 1e-12; k++ {
+		mid := (lo + hi) / 2
+		if studentTCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// incompleteBeta returns the regularized incomplete beta function I_x(a, b),
+// evaluated with the continued fraction of Numerical Recipes (Lentz's method)
+func incompleteBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	// The continued fraction converges quickly for x below (a+1)/(a+b+2)
+	if x > (a+1)/(a+b+2) {
+		return 1 - incompleteBeta(b, a, 1-x)
+	}
+
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab-la-lb+a*math.Log(x)+b*math.Log(1-x)) / a
+
+	const tiny = 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	f := d
+	for m := 1; m <= betaMaxIter; m++ {
+		fm := float64(m)
+		for _, num := range [2]float64{
+			fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm)),
+			-(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1)),
+		} {
+			d = 1 + num*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + num/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			f *= c * d
+		}
+		if math.Abs(c*d-1) < betaTolerance {
+			break
+		}
+	}
+	return front * f
+}
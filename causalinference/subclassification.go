@@ -0,0 +1,59 @@
+package causalinference
+
+import (
+	"math"
+	"sort"
+)
+
+// EstimateBySubclassification bins units into nStrata equal-sized strata on
+// the estimated propensity score, takes the difference in means within each
+// stratum and averages them weighted by stratum size to estimate the ATE.
+// Strata missing either arm are skipped and the remaining weights rescaled.
+// nStrata below 1 defaults to the conventional 5.
+func EstimateBySubclassification(data *CausalData, nStrata int) (EffectResult, error) {
+	if nStrata < 1 {
+		nStrata = 5
+	}
+
+	scores, err := propensityScores(data)
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	// Rank units by score; the k-th quantile bin gets ranks [k*n/s, (k+1)*n/s)
+	n := len(scores)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return scores[order[a]] < scores[order[b]] })
+
+	var estimate, variance float64
+	var used int
+	for k := 0; k < nStrata; k++ {
+		var treated, control []float64
+		for _, i := range order[k*n/nStrata : (k+1)*n/nStrata] {
+			if data.Treatment[i] == 1 {
+				treated = append(treated, data.Outcome[i])
+			} else {
+				control = append(control, data.Outcome[i])
+			}
+		}
+		if len(treated) < 2 || len(control) < 2 {
+			continue
+		}
+
+		m1, se1 := meanAndSE(treated)
+		m0, se0 := meanAndSE(control)
+		size := float64(len(treated) + len(control))
+		estimate += size * (m1 - m0)
+		variance += size * size * (se1*se1 + se0*se0)
+		used += len(treated) + len(control)
+	}
+	if used == 0 {
+		return EffectResult{}, ErrEmptyArm
+	}
+
+	total := float64(used)
+	return newEffectResult("Subclassification", estimate/total, math.Sqrt(variance)/total, used), nil
+}
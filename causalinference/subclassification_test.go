@@ -0,0 +1,24 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSubclassification(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	res, err := EstimateBySubclassification(data, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stratifying on the score should remove most of the confounding bias
+	naive := naiveBias(data)
+	if bias := math.Abs(res.Estimate - data.TrueEffect); bias >= naive/2 {
+		t.Errorf("subclassification bias %.4f not well below naive bias %.4f", bias, naive)
+	}
+	if res.N > len(data.X) || res.SE <= 0 {
+		t.Errorf("invalid result %+v", res)
+	}
+}
@@ -0,0 +1,121 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSurvivalIPCW(t *testing.T) {
+	data := GenerateSurvivalData(8000, 123)
+	truth := data.TrueSurvivalDifference(8)
+
+	// Treatment lowers the hazard, so it should raise survival
+	if truth <= 0 {
+		t.Fatalf("true survival difference %.4f, want positive", truth)
+	}
+
+	var censored int
+	for _, e := range data.Event {
+		censored += 1 - e
+	}
+	if censored == 0 || censored == len(data.Event) {
+		t.Fatalf("%d of %d censored", censored, len(data.Event))
+	}
+
+	res, err := EstimateSurvivalIPCW(data, SurvivalOptions{Horizon: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-truth) > 0.03 {
+		t.Errorf("estimate %.4f too far from truth %.4f", res.Estimate, truth)
+	}
+	if math.Abs(res.Treated-res.Control-res.Estimate) > 1e-12 || res.SE <= 0 {
+		t.Errorf("inconsistent result %+v", res)
+	}
+
+	// Comparing the share surviving among units observed past the horizon
+	// ignores both confounding and censoring
+	var alive, seen [2]float64
+	for i, tm := range data.Time {
+		g := data.Treatment[i]
+		if tm > 8 || data.Event[i] == 1 {
+			seen[g]++
+			if tm > 8 {
+				alive[g]++
+			}
+		}
+	}
+	naive := alive[1]/seen[1] - alive[0]/seen[0]
+	if math.Abs(res.Estimate-truth) >= math.Abs(naive-truth) {
+		t.Errorf("IPCW estimate %.4f no better than naive %.4f (truth %.4f)", res.Estimate, naive, truth)
+	}
+}
+
+func TestSurvivalOptions(t *testing.T) {
+	// Constant hazards without confounding through X: the exponential rate
+	// estimate, events over time at risk, recovers the hazard ratio
+	data := GenerateSurvivalData(20000, 4,
+		WithWeibullHazard(1, 0.2), WithHazardCovariate(0), WithHazardRatio(0.5),
+		WithCensoring(0.05, 0), WithFollowUp(15))
+
+	var events, exposure [2]float64
+	for i, tm := range data.Time {
+		if tm > 15 {
+			t.Fatalf("time %.2f past follow-up", tm)
+		}
+		events[data.Treatment[i]] += float64(data.Event[i])
+		exposure[data.Treatment[i]] += tm
+	}
+	if hr := (events[1] / exposure[1]) / (events[0] / exposure[0]); math.Abs(hr-0.5) > 0.05 {
+		t.Errorf("hazard ratio %.3f, want 0.5", hr)
+	}
+	if rate := events[0] / exposure[0]; math.Abs(rate-0.2) > 0.02 {
+		t.Errorf("control hazard %.3f, want 0.2", rate)
+	}
+
+	// S(t) = exp(-0.2t) in both arms scaled by the hazard ratio
+	want := math.Exp(-0.1*5) - math.Exp(-0.2*5)
+	if got := data.TrueSurvivalDifference(5); math.Abs(got-want) > 1e-6 {
+		t.Errorf("true difference %.6f, want %.6f", got, want)
+	}
+}
+
+func TestSurvivalIPCWWithoutCensoring(t *testing.T) {
+	data := GenerateSurvivalData(8000, 11, WithCensoring(0, 0))
+	truth := data.TrueSurvivalDifference(5)
+
+	// With every event observed the censoring weights are all 1
+	res, err := EstimateSurvivalIPCW(data, SurvivalOptions{Horizon: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-truth) > 0.03 {
+		t.Errorf("estimate %.4f, truth %.4f", res.Estimate, truth)
+	}
+}
+
+func TestSurvivalIPCWAdministrativeCensoring(t *testing.T) {
+	// Censoring by the end of follow-up after the horizon, alone or on top
+	// of random censoring, must not bias the estimate
+	cases := []struct {
+		name          string
+		censoringRate float64
+		followUp      float64
+	}{
+		{"follow-up 6", 0.05, 6},
+		{"follow-up 20", 0.05, 20},
+		{"follow-up only", 0, 6},
+	}
+	for _, c := range cases {
+		data := GenerateSurvivalData(8000, 11, WithCensoring(c.censoringRate, 0.5), WithFollowUp(c.followUp))
+		truth := data.TrueSurvivalDifference(5)
+
+		res, err := EstimateSurvivalIPCW(data, SurvivalOptions{Horizon: 5})
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if math.Abs(res.Estimate-truth) > 0.03 {
+			t.Errorf("%s: estimate %.4f, truth %.4f", c.name, res.Estimate, truth)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package causalinference
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ErrNoPrePeriods is returned when there is no pre-treatment window to fit on
+var ErrNoPrePeriods = errors.New("causalinference: need at least one pre-treatment period")
+
+// Settings for the simplex-constrained least squares solver
+const (
+	synthMaxIter   = 10000
+	synthTolerance = 1e-10
+)
+
+// SynthControlData holds outcome paths for one treated unit and a donor pool
+type SynthControlData struct {
+	Treated    []float64   // outcome path of the treated unit
+	Donors     [][]float64 // Donors[j] is the outcome path of donor j
+	PrePeriods int         // periods before treatment starts
+	TrueEffect float64     // effect on the treated unit in each post period
+}
+
+// SynthControlResult holds the fitted donor weights and the effect path
+type SynthControlResult struct {
+	Weights  []float64 // non-negative donor weights summing to one
+	Gaps     []float64 // treated minus synthetic outcome in every period
+	PreRMSPE float64   // root mean squared gap over the pre-treatment periods
+	ATT      float64   // average gap over the post-treatment periods
+}
+
+// GenerateSynthControlData creates donor paths from a two-factor model and a
+// treated unit that is a fixed convex combination of the first three donors
+// plus noise, shifted by TrueEffect after PrePeriods
+func GenerateSynthControlData(nDonors, nPeriods, prePeriods int, seed int64) *SynthControlData {
+	rng := rand.New(rand.NewSource(seed))
+
+	data := &SynthControlData{
+		Treated:    make([]float64, nPeriods),
+		Donors:     make([][]float64, nDonors),
+		PrePeriods: prePeriods,
+		TrueEffect: -2.0,
+	}
+
+	// Common time factors shared by every unit
+	f1 := make([]float64, nPeriods)
+	f2 := make([]float64, nPeriods)
+	for t := range f1 {
+		f1[t] = 0.1*float64(t) + rng.NormFloat64()
+		f2[t] = math.Sin(float64(t) / 3)
+	}
+
+	for j := range data.Donors {
+		level := 5 * rng.Float64()
+		l1, l2 := rng.NormFloat64(), rng.NormFloat64()
+		data.Donors[j] = make([]float64, nPeriods)
+		for t := range f1 {
+			data.Donors[j][t] = level + l1*f1[t] + l2*f2[t] + 0.1*rng.NormFloat64()
+		}
+	}
+
+	mix := []float64{0.5, 0.3, 0.2}
+	for t := range data.Treated {
+		for j, w := range mix {
+			if j < nDonors {
+				data.Treated[t] += w * data.Donors[j][t]
+			}
+		}
+		data.Treated[t] += 0.1 * rng.NormFloat64()
+		if t >= prePeriods {
+			data.Treated[t] += data.TrueEffect
+		}
+	}
+
+	return data
+}
+
+// EstimateSyntheticControl finds donor weights w >= 0 with sum(w) = 1 that
+// minimise the pre-treatment squared error between the treated path and the
+// weighted donor paths, then reports the gap path. The weights are solved
+// by accelerated projected gradient descent onto the simplex.
+func EstimateSyntheticControl(data *SynthControlData) (SynthControlResult, error) {
+	pre := data.PrePeriods
+	if pre < 1 {
+		return SynthControlResult{}, ErrNoPrePeriods
+	}
+	k := len(data.Donors)
+	if k == 0 {
+		return SynthControlResult{}, ErrEmptyArm
+	}
+
+	// Gram matrix D'D and D'y over the pre-period
+	gram := make([][]float64, k)
+	dty := make([]float64, k)
+	for a := 0; a < k; a++ {
+		gram[a] = make([]float64, k)
+		for b := 0; b < k; b++ {
+			for t := 0; t < pre; t++ {
+				gram[a][b] += data.Donors[a][t] * data.Donors[b][t]
+			}
+		}
+		for t := 0; t < pre; t++ {
+			dty[a] += data.Donors[a][t] * data.Treated[t]
+		}
+	}
+
+	w := simplexLeastSquares(gram, dty)
+
+	result := SynthControlResult{Weights: w, Gaps: make([]float64, len(data.Treated))}
+	var preSS, postSum float64
+	for t := range data.Treated {
+		var synth float64
+		for j := range w {
+			synth += w[j] * data.Donors[j][t]
+		}
+		gap := data.Treated[t] - synth
+		result.Gaps[t] = gap
+		if t < pre {
+			preSS += gap * gap
+		} else {
+			postSum += gap
+		}
+	}
+	result.PreRMSPE = math.Sqrt(preSS / float64(pre))
+	if post := len(data.Treated) - pre; post > 0 {
+		result.ATT = postSum / float64(post)
+	}
+
+	return result, nil
+}
+
+// simplexLeastSquares minimises w'Gw/2 - c'w over the probability simplex
+// using FISTA with a step size of 1/L, where L bounds the largest
+// eigenvalue of G
+func simplexLeastSquares(gram [][]float64, c []float64) []float64 {
+	k := len(c)
+	step := 1 / largestEigenvalue(gram)
+
+	w := make([]float64, k)
+	for j := range w {
+		w[j] = 1 / float64(k)
+	}
+	z := append([]float64(nil), w...)
+	momentum := 1.0
+
+	for iter := 0; iter < synthMaxIter; iter++ {
+		// Gradient step from the extrapolated point, then project
+		next := make([]float64, k)
+		for j := range next {
+			next[j] = z[j] - step*(dot(gram[j], z)-c[j])
+		}
+		next = projectSimplex(next)
+
+		nextMomentum := (1 + math.Sqrt(1+4*momentum*momentum)) / 2
+		var change float64
+		for j := range z {
+			z[j] = next[j] + (momentum-1)/nextMomentum*(next[j]-w[j])
+			change = math.Max(change, math.Abs(next[j]-w[j]))
+		}
+		w, momentum = next, nextMomentum
+		if change < synthTolerance {
+			break
+		}
+	}
+
+	return w
+}
+
+// projectSimplex returns the Euclidean projection of v onto the simplex
+// {w : w >= 0, sum(w) = 1} using the sort-based algorithm of Duchi et al.
+func projectSimplex(v []float64) []float64 {
+	u := append([]float64(nil), v...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(u)))
+
+	var cum, theta float64
+	for i, x := range u {
+		cum += x
+		t := (cum - 1) / float64(i+1)
+		if x-t > 0 {
+			theta = t
+		}
+	}
+
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = math.Max(x-theta, 0)
+	}
+	return out
+}
+
+// largestEigenvalue estimates the top eigenvalue of a symmetric positive
+// semi-definite matrix by power iteration
+func largestEigenvalue(a [][]float64) float64 {
+	v := make([]float64, len(a))
+	for i := range v {
+		v[i] = 1
+	}
+
+	var lambda float64
+	for iter := 0; iter < 100; iter++ {
+		next := make([]float64, len(a))
+		for i := range a {
+			next[i] = dot(a[i], v)
+		}
+		norm := math.Sqrt(dot(next, next))
+		if norm == 0 {
+			return 1
+		}
+		for i := range next {
+			next[i] /= norm
+		}
+		lambda, v = norm, next
+	}
+
+	return lambda
+}
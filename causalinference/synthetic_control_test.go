@@ -0,0 +1,41 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSyntheticControl(t *testing.T) {
+	data := GenerateSynthControlData(20, 40, 30, 42)
+
+	res, err := EstimateSyntheticControl(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Weights must lie on the simplex
+	var sum float64
+	for _, w := range res.Weights {
+		if w < 0 {
+			t.Fatalf("negative weight %f", w)
+		}
+		sum += w
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("weights sum to %f", sum)
+	}
+
+	if math.Abs(res.ATT-data.TrueEffect) > 0.3 {
+		t.Errorf("ATT %.4f too far from %.1f", res.ATT, data.TrueEffect)
+	}
+	if res.PreRMSPE > 0.5 {
+		t.Errorf("poor pre-period fit, RMSPE %.4f", res.PreRMSPE)
+	}
+}
+
+func TestProjectSimplex(t *testing.T) {
+	w := projectSimplex([]float64{0.5, 2, -1})
+	if w[0] != 0 || w[1] != 1 || w[2] != 0 {
+		t.Errorf("unexpected projection %v", w)
+	}
+}
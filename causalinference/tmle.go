@@ -0,0 +1,133 @@
+package causalinference
+
+import "math"
+
+// Bounds used by TMLE, matching the defaults of the R tmle package
+const (
+	tmleGBound = 0.025 // propensity scores are truncated to [g, 1-g]
+	tmleQBound = 0.005 // scaled outcome predictions are kept inside (q, 1-q)
+)
+
+// EstimateTMLE estimates the ATE by targeted maximum likelihood. The
+// outcome is rescaled to [0, 1]; an initial linear outcome model with
+// treatment interactions is then fluctuated along the clever covariate
+// H = T/g - (1-T)/(1-g) by a logistic regression with the initial fit as
+// offset. The standard error comes from the variance of the estimated
+// efficient influence curve, summed within clusters when Cluster is set.
+func EstimateTMLE(data *CausalData) (EffectResult, error) {
+	rows := covariateRows(data)
+	n := len(rows)
+
+	var treated int
+	for _, t := range data.Treatment {
+		treated += t
+	}
+	if treated == 0 || treated == n {
+		return EffectResult{}, ErrEmptyArm
+	}
+
+	// Rescale the outcome to the unit interval for the logistic fluctuation
+	lo, hi := data.Outcome[0], data.Outcome[0]
+	for _, y := range data.Outcome {
+		lo = math.Min(lo, y)
+		hi = math.Max(hi, y)
+	}
+	if hi == lo {
+		return newEffectResult("TMLE", 0, 0, n), nil
+	}
+	ys := make([]float64, n)
+	for i, y := range data.Outcome {
+		ys[i] = (y - lo) / (hi - lo)
+	}
+
+	// Initial outcome model on the scaled outcome
+	x := make([][]float64, n)
+	for i, row := range rows {
+		x[i] = gcompFeatures(row, float64(data.Treatment[i]))
+	}
+	beta, err := fitOLS(x, ys)
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	scores, err := propensityScores(data)
+	if err != nil {
+		return EffectResult{}, err
+	}
+
+	q1 := make([]float64, n)
+	q0 := make([]float64, n)
+	h := make([]float64, n)
+	offset := make([]float64, n)
+	for i, row := range rows {
+		q1[i] = boundProbability(dot(gcompFeatures(row, 1), beta), tmleQBound)
+		q0[i] = boundProbability(dot(gcompFeatures(row, 0), beta), tmleQBound)
+		g := boundProbability(scores[i], tmleGBound)
+		if data.Treatment[i] == 1 {
+			h[i] = 1 / g
+			offset[i] = logit(q1[i])
+		} else {
+			h[i] = -1 / (1 - g)
+			offset[i] = logit(q0[i])
+		}
+	}
+
+	eps := fluctuate(ys, h, offset)
+
+	// Targeted update of both counterfactual predictions
+	diff := make([]float64, n)
+	var psi float64
+	for i := range rows {
+		g := boundProbability(scores[i], tmleGBound)
+		q1[i] = sigmoid(logit(q1[i]) + eps/g)
+		q0[i] = sigmoid(logit(q0[i]) - eps/(1-g))
+		diff[i] = q1[i] - q0[i]
+		psi += diff[i] / float64(n)
+	}
+
+	ic := make([]float64, n)
+	for i := range ic {
+		qa := q0[i]
+		if data.Treatment[i] == 1 {
+			qa = q1[i]
+		}
+		ic[i] = h[i]*(ys[i]-qa) + diff[i] - psi
+	}
+	se := influenceSE(ic, data.Cluster)
+
+	scale := hi - lo
+	return newEffectResult("TMLE", psi*scale, se*scale, n), nil
+}
+
+// fluctuate fits the single-parameter logistic regression of y on h with a
+// fixed offset and no intercept, returning the fitted coefficient
+func fluctuate(y, h, offset []float64) float64 {
+	var eps float64
+	for iter := 0; iter < irlsMaxIter; iter++ {
+		var grad, hess float64
+		for i := range y {
+			mu := sigmoid(offset[i] + eps*h[i])
+			grad += h[i] * (y[i] - mu)
+			hess += h[i] * h[i] * mu * (1 - mu)
+		}
+		if hess == 0 {
+			break
+		}
+		step := grad / hess
+		eps += step
+		if math.Abs(step) < irlsTolerance {
+			break
+		}
+	}
+	return eps
+}
+
+// logit is the inverse of sigmoid
+func logit(p float64) float64 {
+	return math.Log(p / (1 - p))
+}
+
+// boundProbability clamps p into [bound, 1-bound]
+func boundProbability(p, bound float64) float64 {
+	return math.Min(math.Max(p, bound), 1-bound)
+}
@@ -0,0 +1,21 @@
+package causalinference
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateTMLE(t *testing.T) {
+	data := GenerateCausalData(5000, 123)
+
+	res, err := EstimateTMLE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Estimate-data.TrueEffect) > 0.2 {
+		t.Errorf("TMLE estimate %.4f too far from %.1f", res.Estimate, data.TrueEffect)
+	}
+	if res.SE <= 0 || res.SE > 0.5 {
+		t.Errorf("implausible influence-curve SE %.4f", res.SE)
+	}
+}
@@ -0,0 +1,58 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestQiniCurve(t *testing.T) {
+	// Randomized treatment with effect x
+	rng := rand.New(rand.NewSource(5))
+	n := 4000
+	data := &CausalData{X: make([][]float64, n), Treatment: make([]int, n), Outcome: make([]float64, n)}
+	noise := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x := rng.NormFloat64()
+		data.X[i] = []float64{x}
+		data.Treatment[i] = rng.Intn(2)
+		data.Outcome[i] = x + x*float64(data.Treatment[i]) + rng.NormFloat64()
+		noise[i] = rng.Float64()
+	}
+
+	good, err := QiniCurve(data, data.Column(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	random, _ := QiniCurve(data, noise)
+	if good.Coefficient <= 0.1 || math.Abs(random.Coefficient) > 0.05 {
+		t.Errorf("Qini coefficients: true scores %.3f, random %.3f", good.Coefficient, random.Coefficient)
+	}
+	if good.AUUC <= random.AUUC {
+		t.Errorf("AUUC: true scores %.3f, random %.3f", good.AUUC, random.AUUC)
+	}
+	if first := good.Points[0]; first.Fraction != 0 || first.Qini != 0 {
+		t.Error("curve should start at the origin")
+	}
+	if len(good.Points) != n+1 || good.Points[n].Fraction != 1 {
+		t.Errorf("got %d points", len(good.Points))
+	}
+
+	// Tied scores collapse into a single step
+	tied, _ := QiniCurve(data, make([]float64, n))
+	if len(tied.Points) != 2 {
+		t.Errorf("all-tied scores gave %d points", len(tied.Points))
+	}
+
+	// The top 20% by x have E[x | x > 0.84] = 1.40
+	top, err := UpliftAtK(data, data.Column(0), 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(top-1.40) > 0.2 {
+		t.Errorf("uplift at 20%% = %.3f, want about 1.40", top)
+	}
+	if _, err := UpliftAtK(data, data.Column(0), 0); err != ErrInvalidQuantile {
+		t.Errorf("expected ErrInvalidQuantile, got %v", err)
+	}
+}
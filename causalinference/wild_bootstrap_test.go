@@ -0,0 +1,80 @@
+package causalinference
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// fewClusterData has treatment assigned to half of 8 clusters and a
+// cluster-level shock in the outcome
+func fewClusterData(effect float64, seed int64) *CausalData {
+	rng := rand.New(rand.NewSource(seed))
+	n := 400
+	data := &CausalData{X: make([][]float64, n), Treatment: make([]int, n), Outcome: make([]float64, n), Cluster: make([]int, n)}
+	shocks := make([]float64, 8)
+	for g := range shocks {
+		shocks[g] = rng.NormFloat64()
+	}
+	for i := 0; i < n; i++ {
+		g := i % 8
+		data.Cluster[i] = g
+		data.Treatment[i] = g % 2
+		data.X[i] = []float64{rng.NormFloat64()}
+		data.Outcome[i] = data.X[i][0] + effect*float64(data.Treatment[i]) + shocks[g] + rng.NormFloat64()
+	}
+	return data
+}
+
+func TestWildBootstrapRegression(t *testing.T) {
+	res, err := WildBootstrapRegression(fewClusterData(3, 1), RegressionOptions{}, WildBootstrapOptions{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PValue > 0.05 || res.CI[0] > 3 || res.CI[1] < 3 {
+		t.Errorf("p = %.3f, CI %v for an effect of 3", res.PValue, res.CI)
+	}
+
+	// With 8 clusters the normal CR0 test over-rejects; the bootstrap
+	// should not reject more often than it
+	var wild, normal int
+	for s := int64(0); s < 30; s++ {
+		res, err := WildBootstrapRegression(fewClusterData(0, 10+s), RegressionOptions{}, WildBootstrapOptions{Replications: 199, Seed: s})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.PValue <= 0.05 {
+			wild++
+		}
+		if math.Abs(res.Statistic) > z975 {
+			normal++
+		}
+	}
+	if wild > normal || wild > 4 {
+		t.Errorf("null rejections: wild %d, normal %d of 30", wild, normal)
+	}
+
+	if _, err := WildBootstrapRegression(GenerateCausalData(100, 1), RegressionOptions{}, WildBootstrapOptions{}); err != ErrNoClusters {
+		t.Errorf("expected ErrNoClusters, got %v", err)
+	}
+}
+
+func TestWildBootstrapDiD(t *testing.T) {
+	data := GenerateDiDData(600, 2)
+	data.Cluster = make([]int, len(data.Treated))
+	for i := range data.Cluster {
+		data.Cluster[i] = 2*(i%6) + data.Treated[i]
+	}
+
+	res, err := WildBootstrapDiD(data, WildBootstrapOptions{Seed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	did, _ := EstimateDiD(data)
+	if res.Estimate != did.Estimate || res.PValue > 0.05 {
+		t.Errorf("estimate %.4f vs %.4f, p = %.3f", res.Estimate, did.Estimate, res.PValue)
+	}
+	if res.CI[0] > data.TrueEffect || res.CI[1] < data.TrueEffect {
+		t.Errorf("CI %v misses %.1f", res.CI, data.TrueEffect)
+	}
+}
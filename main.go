@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"time"
 
 	"causalinference/causalinference"
@@ -11,20 +12,54 @@ import (
 func main() {
 	// Parse command line flags
 	size := flag.Int("size", 10000, "Size of dataset to generate")
+	name := flag.String("scenario", causalinference.ScenarioDefault, "Simulation scenario to generate data from")
+	list := flag.Bool("list", false, "List the available scenarios and exit")
 	flag.Parse()
 
+	if *list {
+		for _, s := range causalinference.Scenarios() {
+			fmt.Printf("%-28s %s\n", s.Name, s.Description)
+		}
+		return
+	}
+	scenario, err := causalinference.LookupScenario(*name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %q (see -list)\n", err, *name)
+		os.Exit(2)
+	}
+
 	fmt.Printf("Running causal inference with dataset size: %d\n", *size)
+	if scenario.Name != causalinference.ScenarioDefault {
+		fmt.Printf("Scenario: %s\n", scenario.Description)
+	}
 
 	// Generate data
 	start := time.Now()
-	data := causalinference.GenerateCausalData(*size, 123)
+	data := scenario.Generate(*size, 123)
 
 	// Estimate effect
-	effect := causalinference.EstimateCausalEffect(data)
+	effect, err := causalinference.EstimateCausalEffect(data)
 	elapsed := time.Since(start)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	// Print results
-	fmt.Printf("Estimated effect: %.4f\n", effect)
+	fmt.Printf("Estimated effect: %.4f %s\n", effect.Estimate, effect.Significance())
+	fmt.Printf("Standard error: %.4f\n", effect.SE)
+	fmt.Printf("95%% CI: [%.4f, %.4f]\n", effect.CI[0], effect.CI[1])
+	fmt.Printf("Welch t = %.4f, df = %.1f, p-value %s\n", effect.Statistic, effect.DF, formatPValue(effect.PValue))
+	fmt.Println("Signif. codes: 0 '***' 0.001 '**' 0.01 '*' 0.05 '.' 0.1 ' ' 1")
 	fmt.Printf("True effect: %.4f\n", data.TrueEffect)
 	fmt.Printf("Execution time: %.4f seconds\n", elapsed.Seconds())
 }
+
+// formatPValue prints p like R's format.pval, with values below machine
+// precision shown as a bound
+func formatPValue(p float64) string {
+	if p < 2.2e-16 {
+		return "< 2.2e-16"
+	}
+	return fmt.Sprintf("= %.4g", p)
+}